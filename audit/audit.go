@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record one line of the audit log, chained to the previous one by its hash
+type Record struct {
+	Sequence     uint64          `json:"sequence"`
+	ProcessedAt  time.Time       `json:"processed_at"`
+	Decision     json.RawMessage `json:"decision"`
+	PreviousHash string          `json:"previous_hash"`
+	Hash         string          `json:"hash"`
+}
+
+// Position where an audit log stood after a record, enough to bring the log back to it
+type Position struct {
+	Offset   int64  `json:"offset"`
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// Log append-only audit log stored as one json record per line
+type Log struct {
+	mutex        sync.Mutex
+	file         *os.File
+	sequence     uint64
+	previousHash string
+	now          func() time.Time
+}
+
+// Open opens an audit log for appending, continuing the hash chain of the existing records
+func Open(filename string) (*Log, error) {
+	lastRecord, err := lastRecord(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{
+		file:         f,
+		sequence:     lastRecord.Sequence,
+		previousHash: lastRecord.Hash,
+		now:          time.Now,
+	}, nil
+}
+
+// AuditDecision logic.DecisionAuditor implementation appending the evidence to the log
+func (l *Log) AuditDecision(evidence logic.DecisionEvidence) error {
+	return l.Append(evidence)
+}
+
+// Append writes a new record holding the given decision
+func (l *Log) Append(decision interface{}) error {
+	decisionJSON, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	record := Record{
+		Sequence:     l.sequence + 1,
+		ProcessedAt:  l.now().UTC(),
+		Decision:     decisionJSON,
+		PreviousHash: l.previousHash,
+	}
+	record.Hash, err = hashRecord(record)
+	if err != nil {
+		return err
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(l.file, string(recordJSON)); err != nil {
+		return err
+	}
+	l.sequence = record.Sequence
+	l.previousHash = record.Hash
+	return nil
+}
+
+// Position syncs the log to disk and gives its position after the last record
+func (l *Log) Position() (Position, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if err := l.file.Sync(); err != nil {
+		return Position{}, err
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		return Position{}, err
+	}
+	return Position{Offset: info.Size(), Sequence: l.sequence, Hash: l.previousHash}, nil
+}
+
+// Truncate removes the records written after a position, the next records being chained from there, as when resuming
+// a run from a checkpoint taken at that position
+func (l *Log) Truncate(position Position) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < position.Offset {
+		return fmt.Errorf("audit log is shorter than position %d", position.Offset)
+	}
+	if err := l.file.Truncate(position.Offset); err != nil {
+		return err
+	}
+	l.sequence = position.Sequence
+	l.previousHash = position.Hash
+	return nil
+}
+
+// Close closes the underlying file
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// Verify checks the hash chain of an audit log and returns the number of valid records
+func Verify(filename string) (uint64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	previous := Record{}
+	lineScanner := bufio.NewScanner(f)
+	lineScanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for lineScanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(lineScanner.Bytes(), &record); err != nil {
+			return previous.Sequence, fmt.Errorf("record after sequence %d is not readable: %v", previous.Sequence, err)
+		}
+		if record.Sequence != previous.Sequence+1 {
+			return previous.Sequence, fmt.Errorf("record %d follows record %d", record.Sequence, previous.Sequence)
+		}
+		if record.PreviousHash != previous.Hash {
+			return previous.Sequence, fmt.Errorf("record %d is not chained to record %d", record.Sequence, previous.Sequence)
+		}
+		hash, err := hashRecord(record)
+		if err != nil {
+			return previous.Sequence, err
+		}
+		if hash != record.Hash {
+			return previous.Sequence, fmt.Errorf("record %d has been modified", record.Sequence)
+		}
+		previous = record
+	}
+	return previous.Sequence, lineScanner.Err()
+}
+
+// hashRecord hashes a record without its own hash
+func hashRecord(record Record) (string, error) {
+	record.Hash = ""
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(recordJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lastRecord reads the last record of a log, an empty record if the file does not exist
+func lastRecord(filename string) (Record, error) {
+	last := Record{}
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return last, nil
+	}
+	if err != nil {
+		return last, err
+	}
+	defer f.Close()
+	lineScanner := bufio.NewScanner(f)
+	lineScanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for lineScanner.Scan() {
+		if err := json.Unmarshal(lineScanner.Bytes(), &last); err != nil {
+			return last, fmt.Errorf("audit log %s is corrupted: %v", filename, err)
+		}
+	}
+	return last, lineScanner.Err()
+}
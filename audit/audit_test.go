@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLog appends the given decisions to a new audit log, reopening it between each decision
+func writeLog(t *testing.T, filename string, decisions []logic.DecisionEvidence) {
+	for _, decision := range decisions {
+		auditLog, err := Open(filename)
+		if err != nil {
+			t.Fatalf("Open error %v", err)
+		}
+		auditLog.now = func() time.Time { return time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC) }
+		if err := auditLog.AuditDecision(decision); err != nil {
+			t.Fatalf("AuditDecision error %v", err)
+		}
+		if err := auditLog.Close(); err != nil {
+			t.Fatalf("Close error %v", err)
+		}
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	decisions := []logic.DecisionEvidence{
+		{LoadID: "1", CustomerID: "1", PolicyVersion: "1", Accepted: true},
+		{LoadID: "2", CustomerID: "1", PolicyVersion: "1", Accepted: false},
+		{LoadID: "3", CustomerID: "2", PolicyVersion: "1", Accepted: true},
+	}
+	type output struct {
+		records  uint64
+		hasError bool
+	}
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   output
+	}{
+		{
+			name:   "untouched",
+			tamper: func(lines []string) []string { return lines },
+			want:   output{records: 3, hasError: false},
+		},
+		{
+			name: "modifiedDecision",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"accepted":false`, `"accepted":true`, 1)
+				return lines
+			},
+			want: output{records: 1, hasError: true},
+		},
+		{
+			name: "removedRecord",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			want: output{records: 1, hasError: true},
+		},
+		{
+			name: "swappedRecords",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			want: output{records: 0, hasError: true},
+		},
+		{
+			name: "truncatedRecord",
+			tamper: func(lines []string) []string {
+				lines[2] = lines[2][:10]
+				return lines
+			},
+			want: output{records: 2, hasError: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "audit.log")
+			writeLog(t, filename, decisions)
+			content, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("ReadFile error %v", err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(content)), "\n"))
+			if err := ioutil.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+				t.Fatalf("WriteFile error %v", err)
+			}
+			if got, err := Verify(filename); got != tt.want.records || (err != nil) != tt.want.hasError {
+				t.Errorf("Verify = %v and %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseLoadsAudit(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open error %v", err)
+	}
+	loadParser := logic.NewFinanceLogic()
+	loadParser.Auditor = auditLog
+	lineChannel := make(chan string)
+	go func() {
+		lineChannel <- `{"id": "1","customer_id": "1","load_amount": "$4000.00","time": "2018-01-01T00:00:00Z"}`
+		lineChannel <- `{"id": "2","customer_id": "1","load_amount": "$2000.00","time": "2018-01-01T01:00:00Z"}`
+		lineChannel <- `{"id": "2","customer_id": "1","load_amount": "$2000.00","time": "2018-01-01T01:00:00Z"}`
+		close(lineChannel)
+	}()
+	loadParser.ParseLoads(lineChannel)
+	auditLog.Close()
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile error %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit log has %d records, want 2", len(lines))
+	}
+	for _, expected := range []string{`"policy_version":"1"`, `"day_amount":4000`, `"rule":"day_amount","limit":5000,"value":6000,"passed":false`, `"accepted":false`, `"input":{"id":"2"`} {
+		if !strings.Contains(lines[1], expected) {
+			t.Errorf("record %s does not contain %s", lines[1], expected)
+		}
+	}
+	if records, err := Verify(filename); records != 2 || err != nil {
+		t.Errorf("Verify = %v and %v, want 2 records", records, err)
+	}
+}
+
+func Test_Truncate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open error %v", err)
+	}
+	if err := auditLog.AuditDecision(logic.DecisionEvidence{LoadID: "1", CustomerID: "1", Accepted: true}); err != nil {
+		t.Fatalf("AuditDecision error %v", err)
+	}
+	position, err := auditLog.Position()
+	if err != nil || position.Sequence != 1 {
+		t.Fatalf("Position = %v and %v, want sequence 1", position, err)
+	}
+	for _, loadID := range []string{"2", "3"} {
+		if err := auditLog.AuditDecision(logic.DecisionEvidence{LoadID: loadID, CustomerID: "1", Accepted: true}); err != nil {
+			t.Fatalf("AuditDecision error %v", err)
+		}
+	}
+	auditLog.Close()
+	reopened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open error %v", err)
+	}
+	if err := reopened.Truncate(position); err != nil {
+		t.Fatalf("Truncate error %v", err)
+	}
+	if err := reopened.AuditDecision(logic.DecisionEvidence{LoadID: "2", CustomerID: "1", Accepted: true}); err != nil {
+		t.Fatalf("AuditDecision error %v", err)
+	}
+	reopened.Close()
+	if records, err := Verify(filename); records != 2 || err != nil {
+		t.Errorf("Verify = %d and %v, want 2 chained records", records, err)
+	}
+	tooFar := position
+	tooFar.Offset = 1 << 20
+	reopened, _ = Open(filename)
+	defer reopened.Close()
+	if err := reopened.Truncate(tooFar); err == nil {
+		t.Errorf("Truncate past the end of the log gives no error")
+	}
+}
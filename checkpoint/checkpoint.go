@@ -0,0 +1,52 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io/ioutil"
+	"os"
+)
+
+// Checkpoint where a batch run stood, enough to resume it as if it was never interrupted
+// the audit position is only given for runs with an audit log
+type Checkpoint struct {
+	Input        fileutils.Position `json:"input"`
+	OutputOffset int64              `json:"output_offset"`
+	ParsedLines  int                `json:"parsed_lines"`
+	State        logic.State        `json:"state"`
+	Audit        *audit.Position    `json:"audit,omitempty"`
+}
+
+// Load reads a checkpoint file, returns false if there is no checkpoint
+func Load(filename string) (Checkpoint, bool, error) {
+	checkpoint := Checkpoint{}
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return checkpoint, false, nil
+	}
+	if err != nil {
+		return checkpoint, false, err
+	}
+	err = json.Unmarshal(content, &checkpoint)
+	return checkpoint, err == nil, err
+}
+
+// Save writes a checkpoint file, replacing the previous checkpoint atomically
+func Save(filename string, checkpoint Checkpoint) error {
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomic(filename, content)
+}
+
+// Remove deletes the checkpoint file once a run is complete
+func Remove(filename string) error {
+	err := os.Remove(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package checkpoint
+
+import (
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_SaveLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	if _, exist, err := Load(filename); exist || err != nil {
+		t.Errorf("Load without checkpoint = %v and %v, want none", exist, err)
+	}
+	parser := logic.NewFinanceLogic()
+	if _, err := parser.ParseLoad(`{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`); err != nil {
+		t.Fatalf("ParseLoad error %v", err)
+	}
+	parser.HoldLoad(logic.HeldLoad{LoadID: "2", CustomerID: "1", Amount: 5500, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)})
+	saved := Checkpoint{
+		Input:        fileutils.Position{Offset: 90, Fingerprint: "abc"},
+		OutputOffset: 45,
+		ParsedLines:  1,
+		State:        parser.Snapshot(),
+	}
+	if err := Save(filename, saved); err != nil {
+		t.Fatalf("Save error %v", err)
+	}
+	loaded, exist, err := Load(filename)
+	if !exist || err != nil || !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("Load = %v, %v and %v, want %v", loaded, exist, err, saved)
+	}
+	if err := Remove(filename); err != nil {
+		t.Errorf("Remove error %v", err)
+	}
+	if err := Remove(filename); err != nil {
+		t.Errorf("Remove without checkpoint error %v", err)
+	}
+}
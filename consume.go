@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"github.com/vincentcreusot/finance-limits/review"
+	"github.com/vincentcreusot/finance-limits/sink"
+	"github.com/vincentcreusot/finance-limits/stream"
+	"log"
+	"strings"
+)
+
+// consumeLoads validates the loads of a Kafka topic partition until the context is done, producing the responses to
+// the output topic with each response produced exactly once and each decision audited once across restarts
+func consumeLoads(ctx context.Context, options runOptions, parser *logic.FinanceLogic, queue *review.Queue, locks *lockStore, sinks sink.Sink, auditLog *audit.Log) {
+	brokers := strings.Split(options.brokers, ",")
+	producer, err := stream.DialKafkaProducer(ctx, brokers, options.outputTopic, options.partition)
+	if err != nil {
+		log.Fatalln("Error connecting to output topic:", err)
+	}
+	defer func() {
+		if err := producer.Close(); err != nil {
+			log.Println("Error closing output topic:", err)
+		}
+	}()
+	processor := stream.Processor{
+		Engine:             parser,
+		Consumer:           stream.KafkaConsumer{Brokers: brokers, Topic: options.inputTopic, Partition: options.partition},
+		Producer:           producer,
+		CheckpointFileName: options.checkpointFileName,
+		CheckpointInterval: options.checkpointInterval,
+		AuditLog:           auditLog,
+		Restored: func() {
+			if queue != nil {
+				queue.Restore(parser)
+			}
+		},
+		Produced: func(line string) {
+			sendDecision(ctx, sinks, line)
+		},
+		Checkpointing: func() {
+			saveReviewQueue(options, parser, queue)
+			locks.save(parser)
+		},
+	}
+	if err := processor.Run(ctx); err != nil {
+		log.Fatalln("Error consuming loads:", err)
+	}
+	log.Println("Stopped consuming:", ctx.Err())
+}
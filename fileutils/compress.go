@@ -0,0 +1,102 @@
+package fileutils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressor compressing writer whose stream can be ended then restarted on a writer
+type compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// decompressed reader of a file, decompressing it if it starts with the magic bytes of gzip or zstd
+type decompressed struct {
+	reader     io.Reader
+	compressed bool
+	close      func()
+}
+
+// decompress gives a reader of the content of a file, gzip files of several members being read as one
+func decompress(file io.Reader) (decompressed, error) {
+	buffered := bufio.NewReader(file)
+	magic, err := buffered.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return decompressed{}, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return decompressed{}, err
+		}
+		return decompressed{reader: gzipReader, compressed: true, close: func() { gzipReader.Close() }}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, err := zstd.NewReader(buffered)
+		if err != nil {
+			return decompressed{}, err
+		}
+		return decompressed{reader: zstdReader, compressed: true, close: zstdReader.Close}, nil
+	}
+	return decompressed{reader: buffered, close: func() {}}, nil
+}
+
+// newCompressor gives a compressor writing to w for a file ending with .gz or .zst, nil for other files
+func newCompressor(filename string, w io.Writer) (compressor, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gz":
+		return gzip.NewWriter(w), nil
+	case ".zst":
+		return zstd.NewWriter(w)
+	}
+	return nil, nil
+}
+
+// openCompressedAt reads a compressed file from an offset of its decompressed content, the offset staying 0 if the
+// fingerprint of the position does not match
+func openCompressedAt(file *os.File, content io.Reader, start Position) (*followedFile, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	current := &followedFile{file: file, info: info, reader: bufio.NewReader(content)}
+	if start.Offset == 0 {
+		return current, nil
+	}
+	head := make([]byte, minOffset(start.Offset, fingerprintSize))
+	if _, err := io.ReadFull(current.reader, head); err != nil || fingerprint(head) != start.Fingerprint {
+		return current, nil
+	}
+	if _, err := io.CopyN(ioutil.Discard, current.reader, start.Offset-int64(len(head))); err != nil {
+		return current, nil
+	}
+	current.offset = start.Offset
+	current.head = head
+	return current, nil
+}
+
+// countingWriter writer keeping the number of bytes written
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+// Write writes to the underlying writer and counts the bytes written
+func (c *countingWriter) Write(data []byte) (int, error) {
+	written, err := c.writer.Write(data)
+	c.count += int64(written)
+	return written, err
+}
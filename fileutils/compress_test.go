@@ -0,0 +1,131 @@
+package fileutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readAllLines reads the lines of a file with ReadLines
+func readAllLines(filename string) []string {
+	lineChannel := make(chan string)
+	go ReadLines(filename, lineChannel)
+	lines := make([]string, 0)
+	for line := range lineChannel {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func Test_CompressedRoundTrip(t *testing.T) {
+	lines := []string{`{"id":"1","customer_id":"1","accepted":true}`, `{"id":"2","customer_id":"1","accepted":false}`}
+	tests := []struct {
+		name  string
+		file  string
+		magic []byte
+	}{
+		{name: "plain", file: "output.txt"},
+		{name: "gzip", file: "output.txt.gz", magic: gzipMagic},
+		{name: "zstd", file: "output.txt.zst", magic: zstdMagic},
+		{name: "upperCase", file: "OUTPUT.GZ", magic: gzipMagic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.file)
+			if err := WriteLines(filename, lines); err != nil {
+				t.Fatalf("WriteLines error %v", err)
+			}
+			content, err := ioutil.ReadFile(filename)
+			if err != nil || (tt.magic != nil && !bytes.HasPrefix(content, tt.magic)) {
+				t.Errorf("file starts with %x and %v, want %x", content[:4], err, tt.magic)
+			}
+			if got := readAllLines(filename); !reflect.DeepEqual(got, lines) {
+				t.Errorf("ReadLines = %v, want %v", got, lines)
+			}
+		})
+	}
+}
+
+func Test_ReadLinesMultiMemberGzip(t *testing.T) {
+	var content bytes.Buffer
+	for _, member := range []string{"first line\nsecond line\n", "third line\n"} {
+		gzipWriter := gzip.NewWriter(&content)
+		gzipWriter.Write([]byte(member))
+		gzipWriter.Close()
+	}
+	filename := filepath.Join(t.TempDir(), "loads.txt") // detected by its magic bytes
+	if err := ioutil.WriteFile(filename, content.Bytes(), 0644); err != nil {
+		t.Fatalf("WriteFile error %v", err)
+	}
+	want := []string{"first line", "second line", "third line"}
+	if got := readAllLines(filename); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLines = %v, want %v", got, want)
+	}
+}
+
+func Test_CompressedLineWriterAt(t *testing.T) {
+	for _, extension := range []string{".gz", ".zst"} {
+		t.Run(extension, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "output"+extension)
+			writer, err := OpenLineWriterAt(filename, 0)
+			if err != nil {
+				t.Fatalf("OpenLineWriterAt error %v", err)
+			}
+			writer.WriteLine("first line")
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("Flush error %v", err)
+			}
+			checkpoint := writer.Offset()
+			writer.WriteLine("lost line")
+			if err := writer.Close(); err != nil || writer.Offset() <= checkpoint {
+				t.Fatalf("Close = %v with offset %d, want more than %d", err, writer.Offset(), checkpoint)
+			}
+			writer, err = OpenLineWriterAt(filename, checkpoint)
+			if err != nil {
+				t.Fatalf("OpenLineWriterAt error %v", err)
+			}
+			writer.WriteLine("second line")
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close error %v", err)
+			}
+			want := []string{"first line", "second line"}
+			if got := readAllLines(filename); !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadLines = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_ReadLinesFromCompressed(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lines.txt.zst")
+	if err := WriteLines(filename, []string{"first line", "second line", "last line"}); err != nil {
+		t.Fatalf("WriteLines error %v", err)
+	}
+	readAll := func(start Position) ([]Line, error) {
+		lineChannel := make(chan Line)
+		readErrors := make(chan error, 1)
+		go func() {
+			readErrors <- ReadLinesFrom(context.Background(), filename, start, lineChannel)
+		}()
+		lines := make([]Line, 0)
+		for line := range lineChannel {
+			lines = append(lines, line)
+		}
+		return lines, <-readErrors
+	}
+	lines, err := readAll(Position{})
+	if err != nil || len(lines) != 3 || lines[1].Position.Offset != 23 {
+		t.Fatalf("ReadLinesFrom = %v and %v", lines, err)
+	}
+	resumed, err := readAll(lines[0].Position)
+	if err != nil || !reflect.DeepEqual(resumed, lines[1:]) {
+		t.Errorf("ReadLinesFrom position = %v and %v, want %v", resumed, err, lines[1:])
+	}
+	if _, err := readAll(Position{Offset: 11, Fingerprint: "other"}); err == nil {
+		t.Errorf("ReadLinesFrom other file gives no error")
+	}
+}
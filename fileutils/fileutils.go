@@ -2,13 +2,23 @@ package fileutils
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // ReadLines read a file and send each line to a channel
 func ReadLines(inputFileName string, lineChannel chan string) {
+	ReadLinesContext(context.Background(), inputFileName, lineChannel)
+}
+
+// ReadLinesContext read a file and send each line to a channel, stopping when the context is done
+func ReadLinesContext(ctx context.Context, inputFileName string, lineChannel chan string) {
 	defer close(lineChannel)
 	fileBuffer, err := os.Open(inputFileName)
 	if err != nil {
@@ -22,9 +32,20 @@ func ReadLines(inputFileName string, lineChannel chan string) {
 		}
 	}()
 
-	lineScanner := bufio.NewScanner(fileBuffer)
+	content, err := decompress(fileBuffer)
+	if err != nil {
+		log.Print("Error decompressing file ", inputFileName, "with error:", err)
+		return
+	}
+	defer content.close()
+
+	lineScanner := bufio.NewScanner(content.reader)
 	for lineScanner.Scan() {
-		lineChannel <- lineScanner.Text()
+		select {
+		case lineChannel <- lineScanner.Text():
+		case <-ctx.Done():
+			return
+		}
 	}
 	err = lineScanner.Err()
 	if err != nil {
@@ -32,30 +53,286 @@ func ReadLines(inputFileName string, lineChannel chan string) {
 	}
 }
 
-// WriteLines write lines to a file
-// try to remove file if it does not exist
+// WriteLines write lines to a file, compressed if its name ends with .gz or .zst
+// the lines are written to a temporary file renamed to filename once complete, so filename is never partially written
 func WriteLines(filename string, loadsToWrite []string) error {
-	if fileExists(filename) {
-		err := os.Remove(filename)
-		if err != nil {
+	writer, err := CreateAtomic(filename)
+	if err != nil {
+		return err
+	}
+	for _, line := range loadsToWrite {
+		if err := writer.WriteLine(line); err != nil {
+			writer.Abort()
 			return err
 		}
 	}
-	f, err := os.Create(filename)
+	return writer.Commit()
+}
+
+// WriteChannelLines write each line received on a channel to a file until the channel is closed, compressed if its
+// name ends with .gz or .zst, the file being replaced only once all lines are written
+// the channel is drained even if the file cannot be written
+func WriteChannelLines(filename string, lineChannel chan string) error {
+	defer func() {
+		for range lineChannel {
+		}
+	}()
+	writer, err := CreateAtomic(filename)
 	if err != nil {
 		return err
 	}
-	for _, line := range loadsToWrite {
-		fmt.Fprintln(f, line)
+	for line := range lineChannel {
+		if err := writer.WriteLine(line); err != nil {
+			writer.Abort()
+			return err
+		}
+	}
+	return writer.Commit()
+}
+
+// LineWriter writes lines one by one to a file through a buffer, keeping the offset of the end of the file
+// lines of a file whose name ends with .gz or .zst are compressed, each flush ending a gzip member or a zstd frame so
+// the file can be truncated at any flushed offset and appended to
+type LineWriter struct {
+	file       *os.File
+	writer     *bufio.Writer
+	offset     int64
+	compressor compressor
+	counter    *countingWriter
+	pending    bool
+}
+
+// OpenLineWriter opens a file for writing lines, appending to it or replacing it
+func OpenLineWriter(filename string, appendLines bool) (*LineWriter, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendLines {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
 	}
-	if err := f.Close(); err != nil {
+	writer, err := newLineWriter(f, filename, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+// newLineWriter writes lines to an open file from an offset, compressed if filename ends with .gz or .zst
+func newLineWriter(f *os.File, filename string, offset int64) (*LineWriter, error) {
+	counter := &countingWriter{writer: f, count: offset}
+	compressor, err := newCompressor(filename, counter)
+	if err != nil {
+		return nil, err
+	}
+	if compressor == nil {
+		return &LineWriter{file: f, writer: bufio.NewWriter(f), offset: offset}, nil
+	}
+	return &LineWriter{file: f, writer: bufio.NewWriter(compressor), offset: offset, compressor: compressor, counter: counter}, nil
+}
+
+// AtomicWriter writes lines to a temporary file renamed to its file name on Commit, so the file is never partially
+// written and keeps its previous content until then
+// with NoClobber, Commit fails instead of replacing an existing file
+type AtomicWriter struct {
+	*LineWriter
+	NoClobber bool
+	filename  string
+}
+
+// CreateAtomic creates a temporary file next to filename for writing lines, compressed if filename ends with .gz or
+// .zst
+func CreateAtomic(filename string) (*AtomicWriter, error) {
+	tempFile, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	writer, err := newLineWriter(tempFile, filename, 0)
+	if err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return nil, err
+	}
+	return &AtomicWriter{LineWriter: writer, filename: filename}, nil
+}
+
+// Commit writes the buffered lines to disk and renames the temporary file to the file name
+func (w *AtomicWriter) Commit() error {
+	if err := w.Sync(); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	if err := os.Chmod(w.file.Name(), 0644); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	if !w.NoClobber {
+		if err := os.Rename(w.file.Name(), w.filename); err != nil {
+			os.Remove(w.file.Name())
+			return err
+		}
+		return nil
+	}
+	// a link fails if the file exists, where a rename would replace it
+	defer os.Remove(w.file.Name())
+	if err := os.Link(w.file.Name(), w.filename); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("output file %s already exists", w.filename)
+		}
 		return err
 	}
 	return nil
 }
 
-// fileExists tells if a file exists or not and return false if it's a directory
-func fileExists(filename string) bool {
+// Abort removes the temporary file, the file keeping its previous content
+func (w *AtomicWriter) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// OpenLineWriterAt opens a file for writing lines after the given offset, anything after it being removed
+func OpenLineWriterAt(filename string, offset int64) (*LineWriter, error) {
+	if err := os.Truncate(filename, offset); err != nil && !(os.IsNotExist(err) && offset == 0) {
+		return nil, err
+	}
+	return OpenLineWriter(filename, true)
+}
+
+// WriteLine writes one line to the buffer of the file
+func (w *LineWriter) WriteLine(line string) error {
+	written, err := fmt.Fprintln(w.writer, line)
+	if w.compressor == nil {
+		w.offset += int64(written)
+	}
+	w.pending = true
+	return err
+}
+
+// Flush writes the buffered lines to the file, ending the compressed member or frame
+func (w *LineWriter) Flush() error {
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	if w.compressor == nil || !w.pending {
+		return nil
+	}
+	if err := w.compressor.Close(); err != nil {
+		return err
+	}
+	w.compressor.Reset(w.counter)
+	w.pending = false
+	w.offset = w.counter.count
+	return nil
+}
+
+// Offset gives the offset of the end of the file once flushed, a compressed file only growing when flushed
+func (w *LineWriter) Offset() int64 {
+	return w.offset
+}
+
+// Sync flushes the buffered lines and commits the file to disk
+func (w *LineWriter) Sync() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close flushes the buffered lines and closes the underlying file
+func (w *LineWriter) Close() error {
+	if err := w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// ReadLinesFrom read a file from a position and send each line with the position after it to a channel
+// it fails if the start of the file does not match the fingerprint of the position
+func ReadLinesFrom(ctx context.Context, inputFileName string, start Position, lineChannel chan Line) error {
+	defer close(lineChannel)
+	file, err := os.Open(inputFileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	content, err := decompress(file)
+	if err != nil {
+		return err
+	}
+	defer content.close()
+	var current *followedFile
+	if content.compressed {
+		current, err = openCompressedAt(file, content.reader, start)
+	} else if _, err = file.Seek(0, io.SeekStart); err == nil {
+		current, err = openAt(file, start)
+	}
+	if err != nil {
+		return err
+	}
+	if current.offset != start.Offset {
+		return fmt.Errorf("file %s does not match position %d", inputFileName, start.Offset)
+	}
+	for {
+		text, err := current.reader.ReadString('\n')
+		current.read([]byte(text))
+		if text != "" {
+			select {
+			case lineChannel <- Line{Text: strings.TrimRight(text, "\r\n"), Position: current.position()}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WriteFileAtomic writes content to a temporary file synced to disk then renamed to filename, so filename is never
+// partially written
+func WriteFileAtomic(filename string, content []byte) error {
+	tempFile, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+	return os.Rename(tempFile.Name(), filename)
+}
+
+// FileExists tells if a file exists or not and return false if it's a directory
+func FileExists(filename string) bool {
 	info, err := os.Stat(filename)
 	if os.IsNotExist(err) {
 		return false
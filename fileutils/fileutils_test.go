@@ -1,6 +1,9 @@
 package fileutils
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
@@ -103,3 +106,216 @@ func Test_WriteLines(t *testing.T) {
 		})
 	}
 }
+
+func Test_WriteChannelLines(t *testing.T) {
+	tempFolder := t.TempDir()
+	type args struct {
+		filename string
+		lines    []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "newFile",
+			args: args{
+				filename: filepath.Join(tempFolder, "channel.txt"),
+				lines:    []string{"first line", "second line"},
+			},
+			want: false,
+		},
+		{
+			name: "notWritable",
+			args: args{
+				filename: filepath.Join(tempFolder, "notExistingFolder", "notexisting.txt"),
+				lines:    []string{"first line", "second line"},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineChannel := make(chan string)
+			go func() {
+				for _, line := range tt.args.lines {
+					lineChannel <- line
+				}
+				close(lineChannel)
+			}()
+			if got := WriteChannelLines(tt.args.filename, lineChannel); (got != nil) != tt.want {
+				t.Errorf("WriteChannelLines = %v, want %v", got, tt.want)
+			}
+			if !tt.want {
+				readChannel := make(chan string)
+				go ReadLines(tt.args.filename, readChannel)
+				linesRead := make([]string, 0)
+				for line := range readChannel {
+					linesRead = append(linesRead, line)
+				}
+				if !reflect.DeepEqual(linesRead, tt.args.lines) {
+					t.Errorf("WriteChannelLines wrote %v, want %v", linesRead, tt.args.lines)
+				}
+			}
+		})
+	}
+}
+
+func Test_ReadLinesContext(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lines.txt")
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	if err := WriteLines(filename, lines); err != nil {
+		t.Fatalf("WriteLines error %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stringChannel := make(chan string)
+	go ReadLinesContext(ctx, filename, stringChannel)
+	<-stringChannel
+	cancel()
+	received := 1
+	for range stringChannel {
+		received++
+	}
+	if received >= len(lines) {
+		t.Errorf("ReadLinesContext sent %d lines after cancellation, want less than %d", received, len(lines))
+	}
+}
+
+func Test_ReadLinesFrom(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lines.txt")
+	if err := ioutil.WriteFile(filename, []byte("first line\nsecond line\r\nlast line"), 0644); err != nil {
+		t.Fatalf("WriteFile error %v", err)
+	}
+	readAll := func(start Position) ([]Line, error) {
+		lineChannel := make(chan Line)
+		readErrors := make(chan error, 1)
+		go func() {
+			readErrors <- ReadLinesFrom(context.Background(), filename, start, lineChannel)
+		}()
+		lines := make([]Line, 0)
+		for line := range lineChannel {
+			lines = append(lines, line)
+		}
+		return lines, <-readErrors
+	}
+	lines, err := readAll(Position{})
+	if err != nil || !reflect.DeepEqual(lineTexts(lines), []string{"first line", "second line", "last line"}) || lines[1].Position.Offset != 24 {
+		t.Fatalf("ReadLinesFrom = %v and %v", lines, err)
+	}
+	resumed, err := readAll(lines[0].Position)
+	if err != nil || !reflect.DeepEqual(resumed, lines[1:]) {
+		t.Errorf("ReadLinesFrom position = %v and %v, want %v", resumed, err, lines[1:])
+	}
+	if _, err := readAll(Position{Offset: 11, Fingerprint: "other"}); err == nil {
+		t.Errorf("ReadLinesFrom other file gives no error")
+	}
+}
+
+func Test_LineWriterAt(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "output.txt")
+	writer, err := OpenLineWriterAt(filename, 0)
+	if err != nil {
+		t.Fatalf("OpenLineWriterAt error %v", err)
+	}
+	for _, line := range []string{"first line", "second line"} {
+		if err := writer.WriteLine(line); err != nil {
+			t.Fatalf("WriteLine error %v", err)
+		}
+	}
+	if writer.Offset() != 23 {
+		t.Errorf("Offset = %d, want 23", writer.Offset())
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close error %v", err)
+	}
+	writer, err = OpenLineWriterAt(filename, 11)
+	if err != nil {
+		t.Fatalf("OpenLineWriterAt error %v", err)
+	}
+	if err := writer.WriteLine("new second line"); err != nil {
+		t.Fatalf("WriteLine error %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close error %v", err)
+	}
+	if content, _ := ioutil.ReadFile(filename); string(content) != "first line\nnew second line\n" {
+		t.Errorf("file content %q after OpenLineWriterAt", content)
+	}
+}
+
+func Test_AtomicWriter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "output.txt")
+	if err := ioutil.WriteFile(filename, []byte("previous line\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error %v", err)
+	}
+	writer, err := CreateAtomic(filename)
+	if err != nil {
+		t.Fatalf("CreateAtomic error %v", err)
+	}
+	writer.WriteLine("aborted line")
+	writer.Abort()
+	if content, err := ioutil.ReadFile(filename); err != nil || string(content) != "previous line\n" {
+		t.Errorf("aborted file holds %q and %v, want the previous line", content, err)
+	}
+	writer, err = CreateAtomic(filename)
+	if err != nil {
+		t.Fatalf("CreateAtomic error %v", err)
+	}
+	writer.WriteLine("new line")
+	if content, _ := ioutil.ReadFile(filename); string(content) != "previous line\n" {
+		t.Errorf("file holds %q before Commit, want the previous line", content)
+	}
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("Commit error %v", err)
+	}
+	if content, err := ioutil.ReadFile(filename); err != nil || string(content) != "new line\n" {
+		t.Errorf("committed file holds %q and %v, want the new line", content, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("directory holds %d files, want the output file only", len(files))
+	}
+}
+
+func Test_AtomicWriterNoClobber(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "output.txt")
+	writer, err := CreateAtomic(filename)
+	if err != nil {
+		t.Fatalf("CreateAtomic error %v", err)
+	}
+	writer.NoClobber = true
+	writer.WriteLine("first line")
+	if err := ioutil.WriteFile(filename, []byte("created meanwhile\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error %v", err)
+	}
+	if err := writer.Commit(); err == nil {
+		t.Errorf("Commit over an existing file gives no error")
+	}
+	if content, err := ioutil.ReadFile(filename); err != nil || string(content) != "created meanwhile\n" {
+		t.Errorf("file holds %q and %v, want the existing content", content, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("directory holds %d files, want the existing file only", len(files))
+	}
+	os.Remove(filename)
+	writer, err = CreateAtomic(filename)
+	if err != nil {
+		t.Fatalf("CreateAtomic error %v", err)
+	}
+	writer.NoClobber = true
+	writer.WriteLine("new line")
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("Commit error %v", err)
+	}
+	if content, err := ioutil.ReadFile(filename); err != nil || string(content) != "new line\n" {
+		t.Errorf("committed file holds %q and %v, want the new line", content, err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("directory holds %d files, want the output file only", len(files))
+	}
+}
@@ -0,0 +1,252 @@
+package fileutils
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// fingerprintSize number of bytes at the start of a file identifying it across restarts
+const fingerprintSize = 256
+
+// Position place of a line in a followed file
+// the fingerprint identifies the file so a rotated file is not read from an old offset
+type Position struct {
+	Offset      int64  `json:"offset"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Line a line read from a followed file with the position right after it
+type Line struct {
+	Text     string
+	Position Position
+}
+
+// Follower reads lines appended to a file until stopped, reopening it when rotated or truncated
+type Follower struct {
+	FileName     string
+	PollInterval time.Duration
+}
+
+// followedFile the file currently read by a Follower
+type followedFile struct {
+	file   *os.File
+	info   os.FileInfo
+	reader *bufio.Reader
+	offset int64
+	head   []byte
+}
+
+// Follow sends each complete line of the file to a channel starting at the given position
+// it stops and closes the channel when the context is done
+func (f *Follower) Follow(ctx context.Context, start Position, lineChannel chan Line) error {
+	defer close(lineChannel)
+	current, err := f.open(ctx, start)
+	if current == nil {
+		return err
+	}
+	defer func() {
+		if current != nil {
+			current.file.Close()
+		}
+	}()
+	partial := ""
+	for {
+		text, err := current.reader.ReadString('\n')
+		current.read([]byte(text))
+		if err == nil {
+			if !sendLine(ctx, lineChannel, partial+text, current.position()) {
+				return nil
+			}
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial += text
+		rotated, truncated := current.changed(f.FileName)
+		if rotated {
+			// lines written to the old file until the writer reopened the file name are read before switching
+			if done, err := current.drain(ctx, partial, lineChannel); done || err != nil {
+				return err
+			}
+			current.file.Close()
+			partial = ""
+			if current, err = f.open(ctx, Position{}); current == nil {
+				return err
+			}
+			continue
+		}
+		if truncated {
+			if _, err := current.file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			current.reset()
+			partial = ""
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(f.PollInterval):
+		}
+	}
+}
+
+// drain sends the lines left in a rotated file, its last line even if not terminated, it returns true if the context
+// is done
+func (c *followedFile) drain(ctx context.Context, partial string, lineChannel chan Line) (bool, error) {
+	for {
+		text, err := c.reader.ReadString('\n')
+		c.read([]byte(text))
+		partial += text
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if partial != "" && !sendLine(ctx, lineChannel, partial, c.position()) {
+			return true, nil
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		partial = ""
+	}
+}
+
+// sendLine sends a line without its end of line to a channel, it returns false if the context is done first
+func sendLine(ctx context.Context, lineChannel chan Line, text string, position Position) bool {
+	select {
+	case lineChannel <- Line{Text: strings.TrimRight(text, "\r\n"), Position: position}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// open waits for the file to exist and opens it at the given position if its fingerprint matches
+func (f *Follower) open(ctx context.Context, start Position) (*followedFile, error) {
+	for {
+		file, err := os.Open(f.FileName)
+		if err == nil {
+			current, err := openAt(file, start)
+			if err != nil {
+				file.Close()
+			}
+			return current, err
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-time.After(f.PollInterval):
+		}
+	}
+}
+
+// openAt positions an opened file at the start position, at its beginning if it is another file
+func openAt(file *os.File, start Position) (*followedFile, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	current := &followedFile{file: file, info: info}
+	if start.Offset > 0 && start.Offset <= info.Size() {
+		head := make([]byte, minOffset(start.Offset, fingerprintSize))
+		if _, err := io.ReadFull(file, head); err != nil {
+			return nil, err
+		}
+		if fingerprint(head) == start.Fingerprint {
+			if _, err := file.Seek(start.Offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			current.offset = start.Offset
+			current.head = head
+		} else if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	current.reader = bufio.NewReader(file)
+	return current, nil
+}
+
+// read accounts bytes read from the file
+func (c *followedFile) read(data []byte) {
+	if missing := fingerprintSize - len(c.head); missing > 0 {
+		c.head = append(c.head, data[:minOffset(int64(len(data)), int64(missing))]...)
+	}
+	c.offset += int64(len(data))
+}
+
+// reset restarts reading from the beginning of the file
+func (c *followedFile) reset() {
+	c.offset = 0
+	c.head = nil
+	c.reader.Reset(c.file)
+}
+
+// position gives the position after the last byte read
+func (c *followedFile) position() Position {
+	return Position{
+		Offset:      c.offset,
+		Fingerprint: fingerprint(c.head[:minOffset(c.offset, int64(len(c.head)))]),
+	}
+}
+
+// changed tells if the file name now points to another file or if the file was truncated
+func (c *followedFile) changed(fileName string) (bool, bool) {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return false, false
+	}
+	if !os.SameFile(c.info, info) {
+		return true, false
+	}
+	return false, info.Size() < c.offset
+}
+
+// LoadPosition reads a position saved by SavePosition, an empty position if the file does not exist
+func LoadPosition(filename string) (Position, error) {
+	position := Position{}
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return position, nil
+	}
+	if err != nil {
+		return position, err
+	}
+	err = json.Unmarshal(content, &position)
+	return position, err
+}
+
+// SavePosition writes a position to a file, replacing it atomically
+func SavePosition(filename string, position Position) error {
+	content, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filename, content)
+}
+
+// fingerprint hashes the first bytes of a file
+func fingerprint(head []byte) string {
+	sum := sha256.Sum256(head)
+	return hex.EncodeToString(sum[:])
+}
+
+// minOffset gives the smallest of two offsets
+func minOffset(a int64, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,197 @@
+package fileutils
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// appendToFile appends content to a file, creating it if needed
+func appendToFile(t *testing.T, filename string, content string) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile error %v", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("WriteString error %v", err)
+	}
+	f.Close()
+}
+
+// receiveLines waits for a number of lines from a follower
+func receiveLines(t *testing.T, lineChannel chan Line, count int) []Line {
+	lines := make([]Line, 0)
+	for len(lines) < count {
+		select {
+		case line := <-lineChannel:
+			lines = append(lines, line)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %v, want %d lines", lines, count)
+		}
+	}
+	return lines
+}
+
+// lineTexts keeps the text of lines
+func lineTexts(lines []Line) []string {
+	texts := make([]string, 0)
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+	return texts
+}
+
+func Test_Follow(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        func(t *testing.T, filename string)
+		pollInterval time.Duration
+		want         []string
+	}{
+		{
+			name: "appendedLines",
+			steps: func(t *testing.T, filename string) {
+				appendToFile(t, filename, "third line\nfour")
+				time.Sleep(50 * time.Millisecond)
+				appendToFile(t, filename, "th line\n")
+			},
+			want: []string{"first line", "second line", "third line", "fourth line"},
+		},
+		{
+			name: "rotatedFile",
+			steps: func(t *testing.T, filename string) {
+				time.Sleep(50 * time.Millisecond)
+				if err := os.Rename(filename, filename+".1"); err != nil {
+					t.Fatalf("Rename error %v", err)
+				}
+				appendToFile(t, filename, "new first line\n")
+			},
+			want: []string{"first line", "second line", "new first line"},
+		},
+		{
+			name: "rotatedFileWrittenAfter",
+			steps: func(t *testing.T, filename string) {
+				writer, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+				if err != nil {
+					t.Fatalf("OpenFile error %v", err)
+				}
+				defer writer.Close()
+				time.Sleep(50 * time.Millisecond)
+				if err := os.Rename(filename, filename+".1"); err != nil {
+					t.Fatalf("Rename error %v", err)
+				}
+				appendToFile(t, filename, "new first line\n")
+				if _, err := writer.WriteString("third line\nlast line"); err != nil {
+					t.Fatalf("WriteString error %v", err)
+				}
+			},
+			pollInterval: 200 * time.Millisecond,
+			want:         []string{"first line", "second line", "third line", "last line", "new first line"},
+		},
+		{
+			name: "truncatedFile",
+			steps: func(t *testing.T, filename string) {
+				time.Sleep(50 * time.Millisecond)
+				if err := ioutil.WriteFile(filename, []byte("new\n"), 0644); err != nil {
+					t.Fatalf("WriteFile error %v", err)
+				}
+			},
+			want: []string{"first line", "second line", "new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "spool.txt")
+			appendToFile(t, filename, "first line\nsecond line\n")
+			ctx, cancel := context.WithCancel(context.Background())
+			follower := Follower{FileName: filename, PollInterval: tt.pollInterval}
+			if follower.PollInterval == 0 {
+				follower.PollInterval = 10 * time.Millisecond
+			}
+			lineChannel := make(chan Line)
+			done := make(chan error)
+			go func() {
+				done <- follower.Follow(ctx, Position{}, lineChannel)
+			}()
+			lines := receiveLines(t, lineChannel, 2)
+			tt.steps(t, filename)
+			lines = append(lines, receiveLines(t, lineChannel, len(tt.want)-2)...)
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("Follow error %v", err)
+			}
+			if !reflect.DeepEqual(lineTexts(lines), tt.want) {
+				t.Errorf("Follow = %v, want %v", lineTexts(lines), tt.want)
+			}
+		})
+	}
+}
+
+func Test_FollowFromPosition(t *testing.T) {
+	type args struct {
+		content string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "sameFile",
+			args: args{content: "first line\nsecond line\nthird line\n"},
+			want: []string{"third line"},
+		},
+		{
+			name: "otherFile",
+			args: args{content: "other line\nsecond line\nthird line\n"},
+			want: []string{"other line", "second line", "third line"},
+		},
+		{
+			name: "shorterFile",
+			args: args{content: "short\n"},
+			want: []string{"short"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder := t.TempDir()
+			filename := filepath.Join(folder, "spool.txt")
+			positionFileName := filepath.Join(folder, "offset.json")
+			appendToFile(t, filename, "first line\nsecond line\n")
+			ctx, cancel := context.WithCancel(context.Background())
+			follower := Follower{FileName: filename, PollInterval: 10 * time.Millisecond}
+			lineChannel := make(chan Line)
+			go follower.Follow(ctx, Position{}, lineChannel)
+			lines := receiveLines(t, lineChannel, 2)
+			cancel()
+			if err := SavePosition(positionFileName, lines[1].Position); err != nil {
+				t.Fatalf("SavePosition error %v", err)
+			}
+
+			if err := ioutil.WriteFile(filename, []byte(tt.args.content), 0644); err != nil {
+				t.Fatalf("WriteFile error %v", err)
+			}
+			start, err := LoadPosition(positionFileName)
+			if err != nil || start != lines[1].Position {
+				t.Fatalf("LoadPosition = %v and %v, want %v", start, err, lines[1].Position)
+			}
+			ctx, cancel = context.WithCancel(context.Background())
+			defer cancel()
+			lineChannel = make(chan Line)
+			go follower.Follow(ctx, start, lineChannel)
+			if got := lineTexts(receiveLines(t, lineChannel, len(tt.want))); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Follow = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_LoadPosition(t *testing.T) {
+	if got, err := LoadPosition("notexisting.json"); err != nil || got != (Position{}) {
+		t.Errorf("LoadPosition = %v and %v, want empty position", got, err)
+	}
+}
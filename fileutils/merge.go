@@ -0,0 +1,116 @@
+package fileutils
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mergedLine next line of one of the merged files
+type mergedLine struct {
+	text   string
+	time   time.Time
+	source int
+}
+
+// mergeHeap lines ordered by time then by the order of their file
+type mergeHeap []mergedLine
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].time.Equal(h[j].time) {
+		return h[i].source < h[j].source
+	}
+	return h[i].time.Before(h[j].time)
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(mergedLine)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	line := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return line
+}
+
+// InputFiles gives the files of a comma separated list of file names and glob patterns, in the order given, the files
+// of a pattern being sorted by name
+func InputFiles(names string) ([]string, error) {
+	var files []string
+	for _, name := range strings.Split(names, ",") {
+		if name == "" {
+			continue
+		}
+		matches, err := filepath.Glob(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 && strings.ContainsAny(name, "*?[") {
+			return nil, fmt.Errorf("no file matches %s", name)
+		}
+		if len(matches) == 0 {
+			matches = []string{name}
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no input file in %q", names)
+	}
+	return files, nil
+}
+
+// ReadMergedLines read several files, each ordered by time, and send their lines to a channel ordered by the time of
+// the json lines, lines of the same time keeping the order of their files, stopping when the context is done
+// a line without time keeps the time of the line before it in its file
+func ReadMergedLines(ctx context.Context, inputFileNames []string, lineChannel chan string) {
+	if len(inputFileNames) == 1 {
+		ReadLinesContext(ctx, inputFileNames[0], lineChannel)
+		return
+	}
+	defer close(lineChannel)
+	sources := make([]chan string, len(inputFileNames))
+	lastTimes := make([]time.Time, len(inputFileNames))
+	lines := &mergeHeap{}
+	next := func(source int) {
+		text, open := <-sources[source]
+		if !open {
+			return
+		}
+		if lineTime, ok := jsonLineTime(text); ok {
+			lastTimes[source] = lineTime
+		}
+		heap.Push(lines, mergedLine{text: text, time: lastTimes[source], source: source})
+	}
+	for source, inputFileName := range inputFileNames {
+		sources[source] = make(chan string)
+		go ReadLinesContext(ctx, inputFileName, sources[source])
+	}
+	for source := range sources {
+		next(source)
+	}
+	for lines.Len() > 0 {
+		line := heap.Pop(lines).(mergedLine)
+		select {
+		case lineChannel <- line.text:
+		case <-ctx.Done():
+			return
+		}
+		next(line.source)
+	}
+}
+
+// jsonLineTime gives the time field of a json line
+func jsonLineTime(text string) (time.Time, bool) {
+	var timed struct {
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(text), &timed); err != nil || timed.Time.IsZero() {
+		return time.Time{}, false
+	}
+	return timed.Time, true
+}
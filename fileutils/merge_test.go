@@ -0,0 +1,116 @@
+package fileutils
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ReadMergedLines(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt": `{"id":"1","time":"2020-01-06T10:00:00Z"}` + "\n" +
+			`{"id":"3","time":"2020-01-06T12:00:00Z"}` + "\n" +
+			`not json` + "\n" +
+			`{"id":"6","time":"2020-01-06T14:00:00Z"}` + "\n",
+		"b.txt": `{"id":"2","time":"2020-01-06T11:00:00Z"}` + "\n" +
+			`{"id":"4","time":"2020-01-06T12:00:00Z"}` + "\n" +
+			`{"id":"5","time":"2020-01-06T13:00:00Z"}` + "\n",
+		"c.txt": "",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile error %v", err)
+		}
+	}
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{
+			name:  "single",
+			files: []string{"b.txt"},
+			want: []string{
+				`{"id":"2","time":"2020-01-06T11:00:00Z"}`,
+				`{"id":"4","time":"2020-01-06T12:00:00Z"}`,
+				`{"id":"5","time":"2020-01-06T13:00:00Z"}`,
+			},
+		},
+		{
+			name:  "mergedStableOnTies",
+			files: []string{"a.txt", "b.txt", "c.txt"},
+			want: []string{
+				`{"id":"1","time":"2020-01-06T10:00:00Z"}`,
+				`{"id":"2","time":"2020-01-06T11:00:00Z"}`,
+				`{"id":"3","time":"2020-01-06T12:00:00Z"}`,
+				`not json`,
+				`{"id":"4","time":"2020-01-06T12:00:00Z"}`,
+				`{"id":"5","time":"2020-01-06T13:00:00Z"}`,
+				`{"id":"6","time":"2020-01-06T14:00:00Z"}`,
+			},
+		},
+		{
+			name:  "tiesInFileOrder",
+			files: []string{"b.txt", "a.txt"},
+			want: []string{
+				`{"id":"1","time":"2020-01-06T10:00:00Z"}`,
+				`{"id":"2","time":"2020-01-06T11:00:00Z"}`,
+				`{"id":"4","time":"2020-01-06T12:00:00Z"}`,
+				`{"id":"3","time":"2020-01-06T12:00:00Z"}`,
+				`not json`,
+				`{"id":"5","time":"2020-01-06T13:00:00Z"}`,
+				`{"id":"6","time":"2020-01-06T14:00:00Z"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileNames := make([]string, len(tt.files))
+			for i, name := range tt.files {
+				fileNames[i] = filepath.Join(dir, name)
+			}
+			lineChannel := make(chan string)
+			go ReadMergedLines(context.Background(), fileNames, lineChannel)
+			got := make([]string, 0)
+			for line := range lineChannel {
+				got = append(got, line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadMergedLines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_InputFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.txt", "c.json"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("WriteFile error %v", err)
+		}
+	}
+	tests := []struct {
+		name    string
+		names   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single", names: "input.txt", want: []string{"input.txt"}},
+		{name: "list", names: "z.txt,y.txt", want: []string{"z.txt", "y.txt"}},
+		{name: "glob", names: filepath.Join(dir, "*.txt"), want: []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}},
+		{name: "globAndFile", names: filepath.Join(dir, "c.json") + "," + filepath.Join(dir, "a*"), want: []string{filepath.Join(dir, "c.json"), filepath.Join(dir, "a.txt")}},
+		{name: "noMatch", names: filepath.Join(dir, "*.gz"), wantErr: true},
+		{name: "empty", names: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InputFiles(tt.names)
+			if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("InputFiles(%s) = %v and %v, want %v", tt.names, got, err, tt.want)
+			}
+		})
+	}
+}
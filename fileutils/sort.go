@@ -0,0 +1,103 @@
+package fileutils
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+// DefaultChunkLines number of lines sorted in memory at once by default
+const DefaultChunkLines = 1000000
+
+// timedLine line with the time it is sorted by
+type timedLine struct {
+	text string
+	time time.Time
+}
+
+// SortLines sorts the json lines of files by their time into an output file, keeping at most chunkLines lines in
+// memory : chunks are sorted one by one into temporary files of tempDir, then merged
+// lines of the same time keep their order, and a line without time keeps the time of the line before it
+func SortLines(ctx context.Context, inputFileNames []string, outputFileName string, chunkLines int, tempDir string) error {
+	if chunkLines <= 0 {
+		chunkLines = DefaultChunkLines
+	}
+	var chunkFileNames []string
+	defer func() {
+		for _, chunkFileName := range chunkFileNames {
+			os.Remove(chunkFileName)
+		}
+	}()
+	chunk := make([]timedLine, 0, chunkLines)
+	lastTime := time.Time{}
+	for _, inputFileName := range inputFileNames {
+		if _, err := os.Stat(inputFileName); err != nil {
+			return err
+		}
+		lineChannel := make(chan string)
+		go ReadLinesContext(ctx, inputFileName, lineChannel)
+		for text := range lineChannel {
+			if lineTime, ok := jsonLineTime(text); ok {
+				lastTime = lineTime
+			}
+			chunk = append(chunk, timedLine{text: text, time: lastTime})
+			if len(chunk) == chunkLines {
+				chunkFileName, err := writeChunk(chunk, tempDir)
+				if chunkFileName != "" {
+					chunkFileNames = append(chunkFileNames, chunkFileName)
+				}
+				if err != nil {
+					for range lineChannel {
+					}
+					return err
+				}
+				chunk = chunk[:0]
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(chunk) > 0 || len(chunkFileNames) == 0 {
+		chunkFileName, err := writeChunk(chunk, tempDir)
+		if chunkFileName != "" {
+			chunkFileNames = append(chunkFileNames, chunkFileName)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	lineChannel := make(chan string)
+	go ReadMergedLines(ctx, chunkFileNames, lineChannel)
+	if err := WriteChannelLines(outputFileName, lineChannel); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// writeChunk sorts lines by time and writes them to a temporary file
+func writeChunk(chunk []timedLine, tempDir string) (string, error) {
+	sort.SliceStable(chunk, func(i, j int) bool {
+		return chunk[i].time.Before(chunk[j].time)
+	})
+	chunkFile, err := ioutil.TempFile(tempDir, "finance-limits-sort")
+	if err != nil {
+		return "", err
+	}
+	if err := chunkFile.Close(); err != nil {
+		return chunkFile.Name(), err
+	}
+	writer, err := OpenLineWriter(chunkFile.Name(), false)
+	if err != nil {
+		return chunkFile.Name(), err
+	}
+	for _, line := range chunk {
+		if err := writer.WriteLine(line.text); err != nil {
+			writer.Close()
+			return chunkFile.Name(), err
+		}
+	}
+	return chunkFile.Name(), writer.Close()
+}
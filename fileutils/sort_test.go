@@ -0,0 +1,75 @@
+package fileutils
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_SortLines(t *testing.T) {
+	unsorted := []string{
+		`{"id":"1","time":"2020-01-06T13:00:00Z"}`,
+		`{"id":"2","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"3","time":"2020-01-06T12:00:00Z"}`,
+		`{"id":"4","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"5","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"6","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"7","time":"2020-01-06T09:00:00Z"}`,
+	}
+	want := []string{
+		`{"id":"7","time":"2020-01-06T09:00:00Z"}`,
+		`{"id":"2","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"4","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"6","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"5","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"3","time":"2020-01-06T12:00:00Z"}`,
+		`{"id":"1","time":"2020-01-06T13:00:00Z"}`,
+	}
+	tests := []struct {
+		name       string
+		files      [][]string
+		chunkLines int
+		want       []string
+	}{
+		{name: "inMemory", files: [][]string{unsorted}, chunkLines: 100, want: want},
+		{name: "chunks", files: [][]string{unsorted}, chunkLines: 2, want: want},
+		{name: "oneLineChunks", files: [][]string{unsorted}, chunkLines: 1, want: want},
+		{name: "severalFiles", files: [][]string{unsorted[:3], unsorted[3:]}, chunkLines: 3, want: want},
+		{
+			name:       "lineWithoutTime",
+			files:      [][]string{{unsorted[0], "not json", unsorted[1]}},
+			chunkLines: 100,
+			want:       []string{unsorted[1], unsorted[0], "not json"},
+		},
+		{name: "empty", files: [][]string{{}}, chunkLines: 2, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tempDir := t.TempDir()
+			var inputFileNames []string
+			for i, lines := range tt.files {
+				inputFileName := filepath.Join(dir, "input"+string(rune('a'+i))+".txt")
+				if err := WriteLines(inputFileName, lines); err != nil {
+					t.Fatalf("WriteLines error %v", err)
+				}
+				inputFileNames = append(inputFileNames, inputFileName)
+			}
+			outputFileName := filepath.Join(dir, "sorted.txt.gz")
+			if err := SortLines(context.Background(), inputFileNames, outputFileName, tt.chunkLines, tempDir); err != nil {
+				t.Fatalf("SortLines error %v", err)
+			}
+			if got := readAllLines(outputFileName); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortLines = %v, want %v", got, tt.want)
+			}
+			if left, _ := ioutil.ReadDir(tempDir); len(left) != 0 {
+				t.Errorf("SortLines left %d temporary files", len(left))
+			}
+		})
+	}
+	if err := SortLines(context.Background(), []string{"missing.txt"}, filepath.Join(t.TempDir(), "out.txt"), 2, ""); err == nil {
+		t.Errorf("SortLines of a missing file gives no error")
+	}
+}
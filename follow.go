@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/checkpoint"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"github.com/vincentcreusot/finance-limits/review"
+	"github.com/vincentcreusot/finance-limits/sink"
+	"log"
+)
+
+// followLoads validates lines as they are appended to the input file, writing each response immediately
+// with an offset file, the read offset is saved with the engine history each time the end of the input is reached and
+// every checkpoint interval, so a restart resumes after the last saved line with the same history
+// it stops when the context is done
+func followLoads(ctx context.Context, options runOptions, parser *logic.FinanceLogic, queue *review.Queue, locks *lockStore, sinks sink.Sink, auditLog *audit.Log) {
+	current := checkpoint.Checkpoint{}
+	restored := false
+	if options.offsetFileName != "" {
+		current, restored = restoreCheckpoint(options.offsetFileName, parser, queue, auditLog)
+	}
+	if !restored && options.noClobber && fileutils.FileExists(options.outputFileName) {
+		log.Fatalln("Error opening output file:", options.outputFileName, "already exists")
+	}
+	var writer *fileutils.LineWriter
+	var err error
+	if restored {
+		writer, err = fileutils.OpenLineWriterAt(options.outputFileName, current.OutputOffset)
+	} else {
+		writer, err = fileutils.OpenLineWriter(options.outputFileName, false)
+	}
+	if err != nil {
+		log.Fatalln("Error opening output file:", err)
+	}
+	defer func() {
+		if err := writer.Close(); err != nil {
+			log.Println("Error closing output file:", err)
+		}
+	}()
+	follower := fileutils.Follower{
+		FileName:     options.inputFileName,
+		PollInterval: options.pollInterval,
+	}
+	lineChannel := make(chan fileutils.Line)
+	go func() {
+		if err := follower.Follow(ctx, current.Input, lineChannel); err != nil {
+			log.Println("Error following input file:", err)
+		}
+	}()
+	save := func() {
+		if options.offsetFileName != "" {
+			saveCheckpoint(options.offsetFileName, options, parser, queue, locks, writer, auditLog, current)
+		}
+	}
+	errCount := 0
+	parsedLines := 0
+	unsaved := false
+	for {
+		var line fileutils.Line
+		open := true
+		select {
+		case line, open = <-lineChannel:
+		default:
+			if unsaved { // the end of the input is reached, save before waiting for new lines
+				save()
+				unsaved = false
+			}
+			line, open = <-lineChannel
+		}
+		if !open {
+			break
+		}
+		loadResponse, err := parser.ParseLoad(line.Text)
+		if err != nil {
+			log.Printf("Error #%d in load: %v\n", errCount, err)
+			errCount++
+		}
+		if loadResponse != "" {
+			if err := writer.WriteLine(loadResponse); err != nil {
+				log.Fatalln("Error writing line:", err)
+			}
+			if err := writer.Flush(); err != nil {
+				log.Fatalln("Error writing line:", err)
+			}
+			sendDecision(ctx, sinks, loadResponse)
+		}
+		parsedLines++
+		current.Input = line.Position
+		current.ParsedLines++
+		unsaved = true
+		if current.ParsedLines%options.checkpointInterval == 0 {
+			save()
+			unsaved = false
+		}
+	}
+	if unsaved {
+		save()
+	}
+	saveReviewQueue(options, parser, queue)
+	locks.save(parser)
+	log.Printf("Stopped after %d lines at offset %d: %v\n", parsedLines, current.Input.Offset, ctx.Err())
+}
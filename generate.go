@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/generator"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"log"
+	"os"
+	"time"
+)
+
+// generateCommand writes a synthetic input file and optionally the decisions expected for it
+func generateCommand(args []string) {
+	config := generator.DefaultConfig()
+	outputFileName := ""
+	expectedFileName := ""
+	start := config.Start.Format(time.RFC3339)
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	flags.StringVar(&outputFileName, "o", "", "File to write generated loads to")
+	flags.StringVar(&expectedFileName, "expected", "", "File to write the expected responses to")
+	flags.Int64Var(&config.Seed, "seed", config.Seed, "Seed of the random generator")
+	flags.IntVar(&config.Loads, "loads", config.Loads, "Number of loads to generate")
+	flags.IntVar(&config.Customers, "customers", config.Customers, "Number of distinct customers")
+	flags.StringVar(&config.Distribution, "distribution", config.Distribution, "Customer distribution: uniform or zipf")
+	flags.Float64Var(&config.Skew, "skew", config.Skew, "Skew of the zipf distribution, greater than 1")
+	flags.Float64Var(&config.ErrorRate, "error-rate", config.ErrorRate, "Share of malformed lines")
+	flags.Float64Var(&config.DuplicateRate, "duplicate-rate", config.DuplicateRate, "Share of duplicated load ids")
+	flags.Float64Var(&config.OutOfOrderRate, "out-of-order-rate", config.OutOfOrderRate, "Share of loads going back in time")
+	flags.Float64Var(&config.BoundaryRate, "boundary-rate", config.BoundaryRate, "Share of amounts on limit boundaries")
+	flags.StringVar(&start, "start", start, "Time of the first load, RFC3339")
+	flags.DurationVar(&config.Span, "span", config.Span, "Time span covered by the loads")
+	_ = flags.Parse(args)
+	if outputFileName == "" {
+		fmt.Println("flag -o is needed")
+		flags.Usage()
+		os.Exit(1)
+	}
+	startTime, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		log.Fatalln("Error parsing start time:", err)
+	}
+	config.Start = startTime.UTC()
+	loadGenerator, err := generator.NewGenerator(config)
+	if err != nil {
+		log.Fatalln("Error in generator configuration:", err)
+	}
+	lineChannel := make(chan string)
+	go loadGenerator.Generate(lineChannel)
+	if err := fileutils.WriteChannelLines(outputFileName, lineChannel); err != nil {
+		log.Fatalln("Error writing generated loads:", err)
+	}
+	if expectedFileName != "" {
+		writeExpected(outputFileName, expectedFileName)
+	}
+}
+
+// writeExpected runs the reference engine on a generated file
+func writeExpected(inputFileName string, expectedFileName string) {
+	lineToParseChannel := make(chan string)
+	go fileutils.ReadLines(inputFileName, lineToParseChannel)
+	loadsToWrite, _ := logic.NewFinanceLogic().ParseLoads(lineToParseChannel)
+	if err := fileutils.WriteLines(expectedFileName, loadsToWrite); err != nil {
+		log.Fatalln("Error writing expected responses:", err)
+	}
+}
@@ -0,0 +1,207 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// UniformDistribution spreads loads evenly over customers
+	UniformDistribution = "uniform"
+	// ZipfDistribution concentrates loads on a few hot customers
+	ZipfDistribution = "zipf"
+
+	timeLayout    = "2006-01-02T15:04:05Z"
+	maxCentAmount = 600000
+)
+
+// boundaryAmounts amounts sitting on or around the limits
+var boundaryAmounts = []string{"0.00", "0.01", "1666.67", "2500.00", "4999.99", "5000.00", "5000.01", "20000.00"}
+
+// Config parameters of a generated load file
+type Config struct {
+	Seed           int64
+	Loads          int
+	Customers      int
+	Distribution   string
+	Skew           float64
+	ErrorRate      float64
+	DuplicateRate  float64
+	OutOfOrderRate float64
+	BoundaryRate   float64
+	Start          time.Time
+	Span           time.Duration
+}
+
+// generatedLoad a load before being written as a json line
+type generatedLoad struct {
+	LoadID     string
+	CustomerID string
+	Amount     string
+	Time       time.Time
+}
+
+// Generator produces synthetic loads from a Config
+type Generator struct {
+	config    Config
+	random    *rand.Rand
+	zipf      *rand.Zipf
+	generated []generatedLoad
+	nextID    int
+}
+
+// DefaultConfig gives a configuration close to the one of test/input.txt
+func DefaultConfig() Config {
+	return Config{
+		Seed:         1,
+		Loads:        1000,
+		Customers:    500,
+		Distribution: UniformDistribution,
+		Skew:         1.1,
+		Start:        time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Span:         50 * 24 * time.Hour,
+	}
+}
+
+// Validate checks the configuration is usable
+func (c Config) Validate() error {
+	if c.Loads < 0 {
+		return errors.New("number of loads must be positive")
+	}
+	if c.Customers <= 0 {
+		return errors.New("number of customers must be greater than 0")
+	}
+	if c.Distribution != UniformDistribution && c.Distribution != ZipfDistribution {
+		return fmt.Errorf("unknown distribution %q", c.Distribution)
+	}
+	if c.Distribution == ZipfDistribution && c.Skew <= 1 {
+		return errors.New("zipf skew must be greater than 1")
+	}
+	for name, rate := range map[string]float64{
+		"error":        c.ErrorRate,
+		"duplicate":    c.DuplicateRate,
+		"out of order": c.OutOfOrderRate,
+		"boundary":     c.BoundaryRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s rate must be between 0 and 1", name)
+		}
+	}
+	if c.ErrorRate+c.DuplicateRate > 1 {
+		return errors.New("error and duplicate rates sum must not exceed 1")
+	}
+	if c.Span < 0 {
+		return errors.New("time span must be positive")
+	}
+	return nil
+}
+
+// NewGenerator creates a Generator after validating its configuration
+func NewGenerator(config Config) (*Generator, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(config.Seed))
+	generator := &Generator{
+		config:    config,
+		random:    random,
+		generated: make([]generatedLoad, 0),
+		nextID:    1,
+	}
+	if config.Distribution == ZipfDistribution {
+		generator.zipf = rand.NewZipf(random, config.Skew, 1, uint64(config.Customers-1))
+	}
+	return generator, nil
+}
+
+// Generate sends each generated line to a channel and closes it when done
+func (g *Generator) Generate(lineChannel chan string) {
+	defer close(lineChannel)
+	for i := 0; i < g.config.Loads; i++ {
+		lineChannel <- g.nextLine(i)
+	}
+}
+
+// nextLine picks what kind of line to produce for the i-th load
+func (g *Generator) nextLine(i int) string {
+	draw := g.random.Float64()
+	if draw < g.config.ErrorRate {
+		return g.malformedLine(g.newLoad(i))
+	}
+	if draw < g.config.ErrorRate+g.config.DuplicateRate && len(g.generated) > 0 {
+		duplicate := g.generated[g.random.Intn(len(g.generated))]
+		duplicate.Time = g.loadTime(i)
+		return formatLoad(duplicate)
+	}
+	load := g.newLoad(i)
+	g.generated = append(g.generated, load)
+	return formatLoad(load)
+}
+
+// newLoad creates a load with a fresh id
+func (g *Generator) newLoad(i int) generatedLoad {
+	load := generatedLoad{
+		LoadID:     fmt.Sprintf("%d", g.nextID),
+		CustomerID: g.customerID(),
+		Amount:     g.amount(),
+		Time:       g.loadTime(i),
+	}
+	g.nextID++
+	return load
+}
+
+// customerID picks a customer following the configured distribution
+func (g *Generator) customerID() string {
+	if g.zipf != nil {
+		return fmt.Sprintf("%d", g.zipf.Uint64()+1)
+	}
+	return fmt.Sprintf("%d", g.random.Intn(g.config.Customers)+1)
+}
+
+// amount picks either a boundary amount or a random one
+func (g *Generator) amount() string {
+	if g.random.Float64() < g.config.BoundaryRate {
+		return boundaryAmounts[g.random.Intn(len(boundaryAmounts))]
+	}
+	cents := g.random.Intn(maxCentAmount) + 1
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// loadTime spreads loads over the span, moving some of them back in time
+func (g *Generator) loadTime(i int) time.Time {
+	if g.config.Loads == 0 {
+		return g.config.Start
+	}
+	step := g.config.Span / time.Duration(g.config.Loads)
+	loadTime := g.config.Start.Add(step * time.Duration(i))
+	if g.random.Float64() < g.config.OutOfOrderRate && i > 0 {
+		loadTime = g.config.Start.Add(step * time.Duration(g.random.Intn(i)))
+	}
+	return loadTime.Truncate(time.Second)
+}
+
+// malformedLine produces one of the possible broken lines
+func (g *Generator) malformedLine(load generatedLoad) string {
+	line := formatLoad(load)
+	switch g.random.Intn(4) {
+	case 0:
+		return line[:g.random.Intn(len(line)-1)+1]
+	case 1:
+		load.Amount = "AAAA"
+		return formatLoad(load)
+	case 2:
+		return fmt.Sprintf(`{"id":"%s","customer_id":"%s","load_amount":"%s","time":"%s"}`,
+			load.LoadID, load.CustomerID, load.Amount, load.Time.Format(timeLayout))
+	default:
+		return fmt.Sprintf(`{"id":"%s","customer_id":"%s","load_amount":"$%s","time":"yesterday"}`,
+			load.LoadID, load.CustomerID, load.Amount)
+	}
+}
+
+// formatLoad writes a load the same way as the input files
+func formatLoad(load generatedLoad) string {
+	return fmt.Sprintf(`{"id":"%s","customer_id":"%s","load_amount":"$%s","time":"%s"}`,
+		load.LoadID, load.CustomerID, load.Amount, load.Time.Format(timeLayout))
+}
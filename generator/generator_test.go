@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateLines collects every line of a generator
+func generateLines(t *testing.T, config Config) []string {
+	loadGenerator, err := NewGenerator(config)
+	if err != nil {
+		t.Fatalf("NewGenerator error %v", err)
+	}
+	lineChannel := make(chan string)
+	go loadGenerator.Generate(lineChannel)
+	lines := make([]string, 0)
+	for line := range lineChannel {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(c *Config)
+		want   bool
+	}{
+		{
+			name:   "defaultConfig",
+			config: func(c *Config) {},
+			want:   false,
+		},
+		{
+			name:   "noCustomer",
+			config: func(c *Config) { c.Customers = 0 },
+			want:   true,
+		},
+		{
+			name:   "unknownDistribution",
+			config: func(c *Config) { c.Distribution = "normal" },
+			want:   true,
+		},
+		{
+			name:   "zipfSkewTooLow",
+			config: func(c *Config) { c.Distribution = ZipfDistribution; c.Skew = 1 },
+			want:   true,
+		},
+		{
+			name:   "rateAboveOne",
+			config: func(c *Config) { c.BoundaryRate = 1.5 },
+			want:   true,
+		},
+		{
+			name:   "errorAndDuplicateAboveOne",
+			config: func(c *Config) { c.ErrorRate = 0.6; c.DuplicateRate = 0.6 },
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.config(&config)
+			if got := config.Validate(); (got != nil) != tt.want {
+				t.Errorf("Validate = %v, want error %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Generate(t *testing.T) {
+	type load struct {
+		LoadID     string `json:"id"`
+		CustomerID string `json:"customer_id"`
+		Amount     string `json:"load_amount"`
+		Time       string `json:"time"`
+	}
+	tests := []struct {
+		name   string
+		config func(c *Config)
+		check  func(t *testing.T, lines []string)
+	}{
+		{
+			name:   "numberOfLoads",
+			config: func(c *Config) { c.Loads = 50 },
+			check: func(t *testing.T, lines []string) {
+				if len(lines) != 50 {
+					t.Errorf("Generate produced %d lines, want 50", len(lines))
+				}
+			},
+		},
+		{
+			name:   "allMalformed",
+			config: func(c *Config) { c.Loads = 50; c.ErrorRate = 1 },
+			check: func(t *testing.T, lines []string) {
+				for _, line := range lines {
+					var l load
+					if json.Unmarshal([]byte(line), &l) != nil {
+						continue
+					}
+					_, timeErr := time.Parse(time.RFC3339, l.Time)
+					_, amountErr := strconv.ParseFloat(strings.TrimPrefix(l.Amount, "$"), 64)
+					if strings.HasPrefix(l.Amount, "$") && timeErr == nil && amountErr == nil {
+						t.Errorf("line %s is not malformed", line)
+					}
+				}
+			},
+		},
+		{
+			name:   "customersInRange",
+			config: func(c *Config) { c.Customers = 3; c.Distribution = ZipfDistribution },
+			check: func(t *testing.T, lines []string) {
+				for _, line := range lines {
+					var l load
+					if err := json.Unmarshal([]byte(line), &l); err != nil {
+						t.Fatalf("line %s not parsed: %v", line, err)
+					}
+					if l.CustomerID != "1" && l.CustomerID != "2" && l.CustomerID != "3" {
+						t.Errorf("customer %s out of range", l.CustomerID)
+					}
+				}
+			},
+		},
+		{
+			name:   "duplicates",
+			config: func(c *Config) { c.DuplicateRate = 0.5 },
+			check: func(t *testing.T, lines []string) {
+				ids := make(map[string]interface{})
+				duplicates := 0
+				for _, line := range lines {
+					var l load
+					if err := json.Unmarshal([]byte(line), &l); err != nil {
+						t.Fatalf("line %s not parsed: %v", line, err)
+					}
+					if _, exist := ids[l.LoadID+"/"+l.CustomerID]; exist {
+						duplicates++
+					}
+					ids[l.LoadID+"/"+l.CustomerID] = nil
+				}
+				if duplicates == 0 {
+					t.Errorf("no duplicate generated")
+				}
+			},
+		},
+		{
+			name:   "timesInSpan",
+			config: func(c *Config) { c.OutOfOrderRate = 0.5; c.Span = 24 * time.Hour },
+			check: func(t *testing.T, lines []string) {
+				config := DefaultConfig()
+				outOfOrder := false
+				previous := config.Start
+				for _, line := range lines {
+					var l load
+					if err := json.Unmarshal([]byte(line), &l); err != nil {
+						t.Fatalf("line %s not parsed: %v", line, err)
+					}
+					loadTime, _ := time.Parse(time.RFC3339, l.Time)
+					if loadTime.Before(config.Start) || !loadTime.Before(config.Start.Add(24*time.Hour)) {
+						t.Errorf("time %v out of span", loadTime)
+					}
+					if loadTime.Before(previous) {
+						outOfOrder = true
+					}
+					previous = loadTime
+				}
+				if !outOfOrder {
+					t.Errorf("no load out of order")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.config(&config)
+			tt.check(t, generateLines(t, config))
+		})
+	}
+}
+
+func Test_GenerateSeed(t *testing.T) {
+	config := DefaultConfig()
+	config.ErrorRate = 0.1
+	config.DuplicateRate = 0.1
+	config.BoundaryRate = 0.1
+	first := generateLines(t, config)
+	second := generateLines(t, config)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Generate is not reproducible with the same seed")
+	}
+	config.Seed = 2
+	if reflect.DeepEqual(first, generateLines(t, config)) {
+		t.Errorf("Generate gives the same lines with a different seed")
+	}
+}
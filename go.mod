@@ -4,6 +4,8 @@ go 1.15
 
 require (
 	github.com/jinzhu/now v1.1.1
+	github.com/klauspost/compress v1.15.9
+	github.com/segmentio/kafka-go v0.4.47
 	golang.org/x/lint v0.0.0-20200302205851-738671d3881b // indirect
-	golang.org/x/tools v0.0.0-20201017001424-6003fad69a88 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
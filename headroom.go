@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"log"
+	"os"
+	"time"
+)
+
+// headroomCommand replays the loads of the input file and prints what a customer can still load at a time
+func headroomCommand(args []string) {
+	inputFileName := ""
+	policyFileName := ""
+	groupsFileName := ""
+	lockFileName := ""
+	customerID := ""
+	at := ""
+	flags := flag.NewFlagSet("headroom", flag.ExitOnError)
+	flags.StringVar(&inputFileName, "inputFile", "", "File with the loads already received, or comma separated files and glob patterns")
+	flags.StringVar(&inputFileName, "i", "", "File with the loads already received, or comma separated files and glob patterns")
+	flags.StringVar(&policyFileName, "policyFile", "", "Json file with the limits to apply")
+	flags.StringVar(&groupsFileName, "groupsFile", "", "Json file grouping customers sharing limits")
+	flags.StringVar(&lockFileName, "lockFile", "", "Locks of the customers kept between runs")
+	flags.StringVar(&customerID, "customer_id", "", "Customer to give the headroom of")
+	flags.StringVar(&at, "time", "", "Time of the headroom in RFC3339 format, now by default")
+	_ = flags.Parse(args)
+	if inputFileName == "" || customerID == "" {
+		fmt.Println("flags -inputFile and -customer_id are needed")
+		flags.Usage()
+		os.Exit(1)
+	}
+	headroomTime := time.Now().UTC()
+	if at != "" {
+		var err error
+		if headroomTime, err = time.Parse(time.RFC3339, at); err != nil {
+			log.Fatalln("Error in time:", err)
+		}
+	}
+	inputFileNames, err := fileutils.InputFiles(inputFileName)
+	if err != nil {
+		log.Fatalln("Error in input files:", err)
+	}
+	parser := newEngine(policyFileName, groupsFileName)
+	restoreLocks(runOptions{lockFileName: lockFileName}, parser)
+	lineChannel := make(chan string)
+	go fileutils.ReadMergedLines(context.Background(), inputFileNames, lineChannel)
+	_, loadErrors := parser.ParseLoads(lineChannel)
+	for errCount, err := range loadErrors {
+		log.Printf("Error #%d in load: %v\n", errCount, err)
+	}
+	headroom, err := json.MarshalIndent(parser.Headroom(customerID, headroomTime), "", "  ")
+	if err != nil {
+		log.Fatalln("Error writing headroom:", err)
+	}
+	fmt.Println(string(headroom))
+}
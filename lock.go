@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// lockStore the locks of customers kept in a file between runs
+type lockStore struct {
+	fileName string
+	saved    []logic.CustomerLock
+}
+
+// restoreLocks reads the saved locks and gives them to the engine
+func restoreLocks(options runOptions, parser *logic.FinanceLogic) *lockStore {
+	if options.lockFileName == "" {
+		return nil
+	}
+	locks, err := readLocks(options.lockFileName)
+	if err != nil {
+		log.Fatalln("Error reading locks:", err)
+	}
+	for _, lock := range locks {
+		parser.SetLock(lock)
+	}
+	return &lockStore{fileName: options.lockFileName, saved: parser.Locks()}
+}
+
+// save writes the locks of the engine if they changed since last saved, the file being read again first so that a
+// lock released meanwhile with the locks command is also released in the engine instead of being written back
+func (s *lockStore) save(parser *logic.FinanceLogic) {
+	if s == nil {
+		return
+	}
+	current, err := readLocks(s.fileName)
+	if err != nil {
+		log.Println("Error reading locks:", err)
+		return
+	}
+	locks, released := mergeLocks(s.saved, parser.Locks(), current)
+	for _, customerID := range released {
+		_ = parser.ReleaseLock(customerID) // the lock is in the engine, it was saved
+	}
+	if !reflect.DeepEqual(locks, current) {
+		if err := writeLocks(s.fileName, locks); err != nil {
+			log.Println("Error saving locks:", err)
+			return
+		}
+	}
+	s.saved = locks
+}
+
+// mergeLocks merges the locks of the engine with the locks of the file, both changed since the saved ones
+// a saved lock no longer in the file is released if the engine did not change it, and locks added to the file are kept
+// it returns the merged locks ordered by customer and the customers to release in the engine
+func mergeLocks(saved []logic.CustomerLock, engine []logic.CustomerLock, file []logic.CustomerLock) ([]logic.CustomerLock, []string) {
+	savedLocks := make(map[string]logic.CustomerLock, len(saved))
+	for _, lock := range saved {
+		savedLocks[lock.CustomerID] = lock
+	}
+	fileLocks := make(map[string]logic.CustomerLock, len(file))
+	for _, lock := range file {
+		fileLocks[lock.CustomerID] = lock
+	}
+	merged := make([]logic.CustomerLock, 0, len(engine)+len(file))
+	var released []string
+	engineCustomers := make(map[string]bool, len(engine))
+	for _, lock := range engine {
+		engineCustomers[lock.CustomerID] = true
+		savedLock, wasSaved := savedLocks[lock.CustomerID]
+		if _, inFile := fileLocks[lock.CustomerID]; wasSaved && !inFile && sameLock(savedLock, lock) {
+			released = append(released, lock.CustomerID)
+			continue
+		}
+		merged = append(merged, lock)
+	}
+	for _, lock := range file {
+		if _, wasSaved := savedLocks[lock.CustomerID]; !wasSaved && !engineCustomers[lock.CustomerID] {
+			merged = append(merged, lock)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].CustomerID < merged[j].CustomerID
+	})
+	return merged, released
+}
+
+// sameLock tells if two locks are the same
+func sameLock(a logic.CustomerLock, b logic.CustomerLock) bool {
+	return a.CustomerID == b.CustomerID && a.LockedFrom.Equal(b.LockedFrom) && a.LockedUntil.Equal(b.LockedUntil)
+}
+
+// readLocks reads a locks file, no lock if the file does not exist
+func readLocks(filename string) ([]logic.CustomerLock, error) {
+	locks := make([]logic.CustomerLock, 0)
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return locks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, &locks)
+	return locks, err
+}
+
+// writeLocks writes the locks to a file, replacing it atomically
+func writeLocks(filename string, locks []logic.CustomerLock) error {
+	content, err := json.MarshalIndent(locks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomic(filename, content)
+}
+
+// locksCommand lists or releases the locks of customers
+func locksCommand(args []string) {
+	lockFileName := ""
+	customerID := ""
+	flags := flag.NewFlagSet("locks", flag.ExitOnError)
+	flags.StringVar(&lockFileName, "lockFile", "", "Locks of the customers")
+	flags.StringVar(&customerID, "customer_id", "", "Customer to release")
+	flags.Usage = func() {
+		fmt.Println("Usage: locks list|release -lockFile file [-customer_id id]")
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	action := args[0]
+	_ = flags.Parse(args[1:])
+	if lockFileName == "" {
+		fmt.Println("flag -lockFile is needed")
+		flags.Usage()
+		os.Exit(1)
+	}
+	locks, err := readLocks(lockFileName)
+	if err != nil {
+		log.Fatalln("Error reading locks:", err)
+	}
+	switch action {
+	case "list":
+		for _, lock := range locks {
+			fmt.Printf("%s\t%s\t%s\n", lock.CustomerID, lock.LockedFrom.Format(time.RFC3339), lock.LockedUntil.Format(time.RFC3339))
+		}
+		return
+	case "release":
+		parser := logic.NewFinanceLogic()
+		for _, lock := range locks {
+			parser.SetLock(lock)
+		}
+		if err := parser.ReleaseLock(customerID); err != nil {
+			log.Fatalln("Error releasing lock:", err)
+		}
+		locks = parser.Locks()
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+	if err := writeLocks(lockFileName, locks); err != nil {
+		log.Fatalln("Error saving locks:", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/vincentcreusot/finance-limits/logic"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_mergeLocks(t *testing.T) {
+	at := time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC)
+	lock := func(customerID string, hours int) logic.CustomerLock {
+		return logic.CustomerLock{CustomerID: customerID, LockedFrom: at, LockedUntil: at.Add(time.Duration(hours) * time.Hour)}
+	}
+	type output struct {
+		merged   []logic.CustomerLock
+		released []string
+	}
+	tests := []struct {
+		name   string
+		saved  []logic.CustomerLock
+		engine []logic.CustomerLock
+		file   []logic.CustomerLock
+		want   output
+	}{
+		{
+			name:   "newLock",
+			saved:  []logic.CustomerLock{},
+			engine: []logic.CustomerLock{lock("1", 24)},
+			file:   []logic.CustomerLock{},
+			want:   output{merged: []logic.CustomerLock{lock("1", 24)}},
+		},
+		{
+			name:   "releasedInFile",
+			saved:  []logic.CustomerLock{lock("1", 24), lock("2", 24)},
+			engine: []logic.CustomerLock{lock("1", 24), lock("2", 24), lock("3", 24)},
+			file:   []logic.CustomerLock{lock("2", 24)},
+			want:   output{merged: []logic.CustomerLock{lock("2", 24), lock("3", 24)}, released: []string{"1"}},
+		},
+		{
+			name:   "lockedAgainAfterRelease",
+			saved:  []logic.CustomerLock{lock("1", 24)},
+			engine: []logic.CustomerLock{lock("1", 48)},
+			file:   []logic.CustomerLock{},
+			want:   output{merged: []logic.CustomerLock{lock("1", 48)}},
+		},
+		{
+			name:   "addedInFile",
+			saved:  []logic.CustomerLock{},
+			engine: []logic.CustomerLock{lock("2", 24)},
+			file:   []logic.CustomerLock{lock("1", 24)},
+			want:   output{merged: []logic.CustomerLock{lock("1", 24), lock("2", 24)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, released := mergeLocks(tt.saved, tt.engine, tt.file)
+			if !reflect.DeepEqual(merged, tt.want.merged) || !reflect.DeepEqual(released, tt.want.released) {
+				t.Errorf("mergeLocks = %v and %v, want %v", merged, released, tt.want)
+			}
+		})
+	}
+}
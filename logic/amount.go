@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// ZeroAmountRule name of the rule rejecting loads of no amount
+	ZeroAmountRule = "zero_amount"
+	// NegativeAmountRule name of the rule rejecting loads of a negative amount
+	NegativeAmountRule = "negative_amount"
+	// AmountPrecisionRule name of the rule rejecting amounts with more than two decimals
+	AmountPrecisionRule = "amount_precision"
+	// LoadMinAmountRule name of the rule rejecting loads under the minimum amount
+	LoadMinAmountRule = "load_min_amount"
+	// LoadMaxAmountRule name of the rule rejecting loads over the maximum amount
+	LoadMaxAmountRule = "load_max_amount"
+
+	// LoadLevel rules applied to a single load whatever the history
+	LoadLevel = "load"
+)
+
+// amountPattern an amount in dollars like $123.45, negative ones being written -$123.45
+var amountPattern = regexp.MustCompile(`^-?\$[0-9]+(\.[0-9]+)?$`)
+
+// parseAmount parses a dollar amount, rejecting signs after the dollar, exponents and other float notations
+func parseAmount(amountStr string) (float64, error) {
+	if !amountPattern.MatchString(amountStr) {
+		return 0, fmt.Errorf("invalid amount %q", amountStr)
+	}
+	negative := amountStr[0] == '-'
+	if negative {
+		amountStr = amountStr[1:]
+	}
+	amount, err := strconv.ParseFloat(amountStr[1:], 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// validateLoadAmount gives the outcome of the rules applied to the amount of the load alone
+// each rule is only given when enabled or set in the policy
+func validateLoadAmount(load Load, policy Policy) []RuleOutcome {
+	amount := load.Amount.Value
+	var outcomes []RuleOutcome
+	if policy.RejectZeroAmount {
+		outcomes = append(outcomes, RuleOutcome{Rule: ZeroAmountRule, Value: amount, Passed: amount != 0, Level: LoadLevel})
+	}
+	if policy.RejectNegativeAmount {
+		outcomes = append(outcomes, RuleOutcome{Rule: NegativeAmountRule, Value: amount, Passed: amount >= 0, Level: LoadLevel})
+	}
+	if policy.RejectSubCentAmount {
+		outcomes = append(outcomes, RuleOutcome{Rule: AmountPrecisionRule, Limit: 2, Value: amount, Passed: hasCents(amount), Level: LoadLevel})
+	}
+	if policy.LoadMinAmount != 0 {
+		outcomes = append(outcomes, RuleOutcome{
+			Rule:   LoadMinAmountRule,
+			Limit:  policy.LoadMinAmount,
+			Value:  amount,
+			Passed: amount >= policy.LoadMinAmount,
+			Level:  LoadLevel,
+		})
+	}
+	if policy.LoadMaxAmount != 0 {
+		outcomes = append(outcomes, RuleOutcome{
+			Rule:   LoadMaxAmountRule,
+			Limit:  policy.LoadMaxAmount,
+			Value:  amount,
+			Passed: amount <= policy.LoadMaxAmount,
+			Level:  LoadLevel,
+		})
+	}
+	return outcomes
+}
+
+// hasCents tells if an amount has at most two decimals
+func hasCents(amount float64) bool {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(amount, 'f', 2, 64), 64)
+	return rounded == amount
+}
+
+// rejectionReasons gives the failed load rules of an evaluation and the lockout of the customer
+func rejectionReasons(rules []RuleOutcome) []string {
+	var reasons []string
+	for _, outcome := range rules {
+		if !outcome.Passed && (outcome.Level == LoadLevel || outcome.Rule == LockoutRule) {
+			reasons = append(reasons, outcome.Rule)
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseAmount(t *testing.T) {
+	type output struct {
+		amount   float64
+		hasError bool
+	}
+	tests := []struct {
+		name      string
+		amountStr string
+		want      output
+	}{
+		{name: "cents", amountStr: "$123.45", want: output{amount: 123.45}},
+		{name: "noDecimals", amountStr: "$5000", want: output{amount: 5000}},
+		{name: "negative", amountStr: "-$10.00", want: output{amount: -10}},
+		{name: "moreDecimals", amountStr: "$1.005", want: output{amount: 1.005}},
+		{name: "signAfterDollar", amountStr: "$-10.00", want: output{hasError: true}},
+		{name: "plusSign", amountStr: "$+10.00", want: output{hasError: true}},
+		{name: "exponent", amountStr: "$1e3", want: output{hasError: true}},
+		{name: "noDollar", amountStr: "10.00", want: output{hasError: true}},
+		{name: "infinity", amountStr: "$Inf", want: output{hasError: true}},
+		{name: "trailingDot", amountStr: "$10.", want: output{hasError: true}},
+		{name: "space", amountStr: "$ 10.00", want: output{hasError: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := parseAmount(tt.amountStr)
+			if amount != tt.want.amount || (err != nil) != tt.want.hasError {
+				t.Errorf("parseAmount = %v and %v, want %v", amount, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateLoadAmount(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.LoadMinAmount = 10
+	policy.LoadMaxAmount = 2500
+	policy.RejectZeroAmount = true
+	policy.RejectNegativeAmount = true
+	policy.RejectSubCentAmount = true
+	tests := []struct {
+		name     string
+		amount   float64
+		disabled bool
+		want     []string
+	}{
+		{name: "valid", amount: 100, want: nil},
+		{name: "zero", amount: 0, want: []string{ZeroAmountRule, LoadMinAmountRule}},
+		{name: "negative", amount: -20, want: []string{NegativeAmountRule, LoadMinAmountRule}},
+		{name: "precision", amount: 100.001, want: []string{AmountPrecisionRule}},
+		{name: "underMinimum", amount: 9.99, want: []string{LoadMinAmountRule}},
+		{name: "atMaximum", amount: 2500, want: nil},
+		{name: "overMaximum", amount: 4999.99, want: []string{LoadMaxAmountRule}},
+		{name: "zeroNotRejected", amount: 0, disabled: true, want: nil},
+		{name: "negativeNotRejected", amount: -20, disabled: true, want: nil},
+		{name: "precisionNotRejected", amount: 100.001, disabled: true, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := Load{LoadID: "1", CustomerID: "1", Amount: Amount{Value: tt.amount}, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)}
+			loadPolicy := policy
+			if tt.disabled {
+				loadPolicy = DefaultPolicy()
+			}
+			if got := rejectionReasons(validateLoadAmount(load, loadPolicy)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateLoadAmount reasons = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseLoadRejectionReasons(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "accepted",
+			line: `{"id":"1","customer_id":"1","load_amount":"$100.00","time":"2020-01-06T10:00:00Z"}`,
+			want: `{"id":"1","customer_id":"1","accepted":true}`,
+		},
+		{
+			name: "zero",
+			line: `{"id":"1","customer_id":"1","load_amount":"$0.00","time":"2020-01-06T10:00:00Z"}`,
+			want: `{"id":"1","customer_id":"1","accepted":false,"rejection_reasons":["zero_amount"]}`,
+		},
+		{
+			name: "negative",
+			line: `{"id":"1","customer_id":"1","load_amount":"-$50.00","time":"2020-01-06T10:00:00Z"}`,
+			want: `{"id":"1","customer_id":"1","accepted":false,"rejection_reasons":["negative_amount"]}`,
+		},
+		{
+			name: "aggregateLimit",
+			line: `{"id":"1","customer_id":"1","load_amount":"$5000.01","time":"2020-01-06T10:00:00Z"}`,
+			want: `{"id":"1","customer_id":"1","accepted":false}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			loadParser.Policies[0].RejectZeroAmount = true
+			loadParser.Policies[0].RejectNegativeAmount = true
+			if got, err := loadParser.ParseLoad(tt.line); got != tt.want || err != nil {
+				t.Errorf("ParseLoad = %v and %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
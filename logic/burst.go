@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// BurstCountRule name of the rule limiting the number of loads over a short duration
+	BurstCountRule = "burst_count"
+	// BurstAmountRule name of the rule limiting the amount loaded over a short duration
+	BurstAmountRule = "burst_amount"
+)
+
+// Duration a time.Duration written as a string like 10m in json
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON implementation writing a Duration as 10m0s
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implementation of parsing of 10m to a Duration
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var durationStr string
+	if err := json.Unmarshal(b, &durationStr); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// BurstLimit limits on the loads of a customer over a rolling duration ending at the time of the load, 0 disables
+// the limit
+type BurstLimit struct {
+	Duration  Duration `json:"duration"`
+	MaxCount  int      `json:"max_count,omitempty"`
+	MaxAmount float64  `json:"max_amount,omitempty"`
+}
+
+// Validate checks a burst limit has a duration and at least one limit
+func (b BurstLimit) Validate() error {
+	if b.Duration.Duration <= 0 {
+		return errors.New("burst duration must be positive")
+	}
+	if b.MaxCount < 0 || b.MaxAmount < 0 {
+		return errors.New("burst limits must be positive")
+	}
+	if b.MaxCount == 0 && b.MaxAmount == 0 {
+		return errors.New("burst limit needs a maximum count or amount")
+	}
+	return nil
+}
+
+// validateBurstLoad gives the outcome of the burst limits of the policy using the loads of the customer counting in
+// the number of loads and in the amount
+func validateBurstLoad(load Load, countLoads []Load, amountLoads []Load, policy Policy) []RuleOutcome {
+	outcomes := make([]RuleOutcome, 0)
+	for _, burst := range policy.BurstLimits {
+		amount, count := burstUsage(load, amountLoads, burst.Duration.Duration)
+		if !sameLoads(countLoads, amountLoads) {
+			_, count = burstUsage(load, countLoads, burst.Duration.Duration)
+		}
+		if burst.MaxCount != 0 {
+			outcomes = append(outcomes, RuleOutcome{
+				Rule:   BurstCountRule,
+				Window: burst.Duration.String(),
+				Limit:  float64(burst.MaxCount),
+				Value:  float64(count + 1),
+				Passed: count < burst.MaxCount,
+				Level:  CustomerLevel,
+			})
+		}
+		if burst.MaxAmount != 0 {
+			outcomes = append(outcomes, RuleOutcome{
+				Rule:   BurstAmountRule,
+				Window: burst.Duration.String(),
+				Limit:  burst.MaxAmount,
+				Value:  amount + load.Amount.Value,
+				Passed: amount+load.Amount.Value <= burst.MaxAmount,
+				Level:  CustomerLevel,
+			})
+		}
+	}
+	return outcomes
+}
+
+// burstUsage sums amounts and counts loads in the duration ending at the time of a load
+func burstUsage(load Load, loads []Load, duration time.Duration) (float64, int) {
+	windowStart := load.Time.Add(-duration)
+	amountSum := float64(0)
+	count := 0
+	for _, storedLoad := range loads {
+		if storedLoad.Time.After(windowStart) && !storedLoad.Time.After(load.Time) {
+			count++
+			amountSum += storedLoad.Amount.Value
+		}
+	}
+	return amountSum, count
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func Test_validateBurstLoad(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.BurstLimits = []BurstLimit{
+		{Duration: Duration{10 * time.Minute}, MaxCount: 2},
+		{Duration: Duration{time.Hour}, MaxAmount: 1000},
+	}
+	history := []Load{
+		{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 300}, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)},
+		{LoadID: "2", CustomerID: "1", Amount: Amount{Value: 300}, Time: time.Date(2020, time.January, 6, 10, 5, 0, 0, time.UTC)},
+	}
+	type output struct {
+		accepted bool
+		failed   []string
+	}
+	tests := []struct {
+		name string
+		load Load
+		want output
+	}{
+		{
+			name: "countInWindow",
+			load: Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 100}, Time: time.Date(2020, time.January, 6, 10, 9, 59, 0, time.UTC)},
+			want: output{accepted: false, failed: []string{BurstCountRule}},
+		},
+		{
+			name: "countAfterWindow",
+			load: Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 100}, Time: time.Date(2020, time.January, 6, 10, 10, 0, 0, time.UTC)},
+			want: output{accepted: true},
+		},
+		{
+			name: "amountInWindow",
+			load: Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 500}, Time: time.Date(2020, time.January, 6, 10, 30, 0, 0, time.UTC)},
+			want: output{accepted: false, failed: []string{BurstAmountRule}},
+		},
+		{
+			name: "amountAtLimit",
+			load: Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 400}, Time: time.Date(2020, time.January, 6, 10, 30, 0, 0, time.UTC)},
+			want: output{accepted: true},
+		},
+		{
+			name: "laterLoadsNotCounted",
+			load: Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 500}, Time: time.Date(2020, time.January, 6, 9, 59, 0, 0, time.UTC)},
+			want: output{accepted: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateLoad(tt.load, history, PolicyHistory{policy})
+			failed := make([]string, 0)
+			for _, outcome := range got.Rules {
+				if !outcome.Passed {
+					failed = append(failed, outcome.Rule)
+				}
+			}
+			if got.Accepted != tt.want.accepted || len(failed) != len(tt.want.failed) || (len(failed) > 0 && failed[0] != tt.want.failed[0]) {
+				t.Errorf("validateLoad = %v with failed rules %v, want %v", got.Accepted, failed, tt.want)
+			}
+		})
+	}
+}
+
+func Test_BurstLimitJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		hasError bool
+	}{
+		{name: "valid", content: `{"duration": "10m", "max_count": 2}`, hasError: false},
+		{name: "invalidDuration", content: `{"duration": "ten minutes", "max_count": 2}`, hasError: true},
+		{name: "noDuration", content: `{"max_count": 2}`, hasError: true},
+		{name: "noLimit", content: `{"duration": "10m"}`, hasError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var burst BurstLimit
+			err := json.Unmarshal([]byte(tt.content), &burst)
+			if err == nil {
+				err = burst.Validate()
+			}
+			if (err != nil) != tt.hasError {
+				t.Errorf("BurstLimit error %v, want error %v", err, tt.hasError)
+			}
+		})
+	}
+}
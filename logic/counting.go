@@ -0,0 +1,109 @@
+package logic
+
+import (
+	"time"
+)
+
+const (
+	// RejectedLoadKind a load rejected by a rule
+	RejectedLoadKind = "rejected"
+	// DuplicateLoadKind a load whose id was already treated for the customer
+	DuplicateLoadKind = "duplicate"
+	// HeldLoadKind a load waiting for a review
+	HeldLoadKind = "held"
+)
+
+// LoadCounting tells if a kind of loads counts in the number of loads and in the amount of the windows
+type LoadCounting struct {
+	Count  bool `json:"count"`
+	Amount bool `json:"amount"`
+}
+
+// Counting the loads counting in the windows besides the accepted ones, none by default
+type Counting struct {
+	Rejected  LoadCounting `json:"rejected"`
+	Duplicate LoadCounting `json:"duplicate"`
+	Held      LoadCounting `json:"held"`
+}
+
+// of gives the counting of a kind of loads
+func (c Counting) of(kind string) LoadCounting {
+	switch kind {
+	case RejectedLoadKind:
+		return c.Rejected
+	case DuplicateLoadKind:
+		return c.Duplicate
+	case HeldLoadKind:
+		return c.Held
+	}
+	return LoadCounting{}
+}
+
+// CountedLoad a load not accepted but kept to count in the windows of the next loads
+type CountedLoad struct {
+	Load Load   `json:"load"`
+	Kind string `json:"kind"`
+}
+
+// recordCounted keeps a rejected or duplicate load if the policy effective at its time counts its kind
+func (logic *FinanceLogic) recordCounted(load Load, kind string, policy Policy) {
+	counting := policy.Counting.of(kind)
+	if !counting.Count && !counting.Amount {
+		return
+	}
+	logic.CountedLoads[load.CustomerID] = append(logic.CountedLoads[load.CustomerID], CountedLoad{Load: load, Kind: kind})
+}
+
+// windowLoads gives the loads of customers counting in the number of loads and those counting in the amount of the
+// windows of a load at a time, the accepted ones being given, reserved loads not expired at that time counting in both
+func (logic *FinanceLogic) windowLoads(customerIDs []string, accepted []Load, policy Policy, at time.Time) ([]Load, []Load) {
+	reserved := logic.activeReservations(customerIDs, at)
+	if policy.Counting == (Counting{}) && len(reserved) == 0 {
+		return accepted, accepted
+	}
+	extraCount := append(make([]Load, 0), reserved...)
+	extraAmount := append(make([]Load, 0), reserved...)
+	add := func(load Load, kind string) {
+		counting := policy.Counting.of(kind)
+		if counting.Count {
+			extraCount = append(extraCount, load)
+		}
+		if counting.Amount {
+			extraAmount = append(extraAmount, load)
+		}
+	}
+	customers := make(map[string]interface{}, len(customerIDs))
+	for _, customerID := range customerIDs {
+		customers[customerID] = nil
+		for _, counted := range logic.CountedLoads[customerID] {
+			add(counted.Load, counted.Kind)
+		}
+	}
+	if policy.Counting.Held.Count || policy.Counting.Held.Amount {
+		held := make([]HeldLoad, 0)
+		for _, load := range logic.HeldLoads {
+			if _, exist := customers[load.CustomerID]; exist {
+				held = append(held, toHeldLoad(load))
+			}
+		}
+		sortHeldLoads(held) // sums do not depend on the order of the map
+		for _, load := range held {
+			add(fromHeldLoad(load), HeldLoadKind)
+		}
+	}
+	return withLoads(accepted, extraCount), withLoads(accepted, extraAmount)
+}
+
+// withLoads gives the accepted loads followed by the extra ones without modifying the accepted ones
+func withLoads(accepted []Load, extra []Load) []Load {
+	if len(extra) == 0 {
+		return accepted
+	}
+	loads := make([]Load, 0, len(accepted)+len(extra))
+	return append(append(loads, accepted...), extra...)
+}
+
+// sameLoads tells if two lists of loads are the same slice
+func sameLoads(a []Load, b []Load) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Counting(t *testing.T) {
+	setups := map[string][]string{
+		RejectedLoadKind: {
+			`{"id":"1","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:00:00Z"}`,
+		},
+		DuplicateLoadKind: {
+			`{"id":"1","customer_id":"1","load_amount":"$100.00","time":"2020-01-06T09:00:00Z"}`,
+			`{"id":"1","customer_id":"1","load_amount":"$3000.00","time":"2020-01-06T10:00:00Z"}`,
+		},
+		HeldLoadKind: {
+			`{"id":"1","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:00:00Z"}`,
+		},
+	}
+	type output struct {
+		dayCount  int
+		dayAmount float64
+	}
+	tests := []struct {
+		name     string
+		kind     string
+		counting LoadCounting
+		want     output
+	}{
+		{name: "rejectedNotCounted", kind: RejectedLoadKind, counting: LoadCounting{}, want: output{dayCount: 0, dayAmount: 0}},
+		{name: "rejectedCount", kind: RejectedLoadKind, counting: LoadCounting{Count: true}, want: output{dayCount: 1, dayAmount: 0}},
+		{name: "rejectedAmount", kind: RejectedLoadKind, counting: LoadCounting{Amount: true}, want: output{dayCount: 0, dayAmount: 6000}},
+		{name: "rejectedBoth", kind: RejectedLoadKind, counting: LoadCounting{Count: true, Amount: true}, want: output{dayCount: 1, dayAmount: 6000}},
+		{name: "duplicateNotCounted", kind: DuplicateLoadKind, counting: LoadCounting{}, want: output{dayCount: 1, dayAmount: 100}},
+		{name: "duplicateCount", kind: DuplicateLoadKind, counting: LoadCounting{Count: true}, want: output{dayCount: 2, dayAmount: 100}},
+		{name: "duplicateAmount", kind: DuplicateLoadKind, counting: LoadCounting{Amount: true}, want: output{dayCount: 1, dayAmount: 3100}},
+		{name: "duplicateBoth", kind: DuplicateLoadKind, counting: LoadCounting{Count: true, Amount: true}, want: output{dayCount: 2, dayAmount: 3100}},
+		{name: "heldNotCounted", kind: HeldLoadKind, counting: LoadCounting{}, want: output{dayCount: 0, dayAmount: 0}},
+		{name: "heldCount", kind: HeldLoadKind, counting: LoadCounting{Count: true}, want: output{dayCount: 1, dayAmount: 0}},
+		{name: "heldAmount", kind: HeldLoadKind, counting: LoadCounting{Amount: true}, want: output{dayCount: 0, dayAmount: 6000}},
+		{name: "heldBoth", kind: HeldLoadKind, counting: LoadCounting{Count: true, Amount: true}, want: output{dayCount: 1, dayAmount: 6000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			policy := &loadParser.Policies[0]
+			switch tt.kind {
+			case RejectedLoadKind:
+				policy.Counting.Rejected = tt.counting
+			case DuplicateLoadKind:
+				policy.Counting.Duplicate = tt.counting
+			case HeldLoadKind:
+				policy.Counting.Held = tt.counting
+				policy.DayReviewMaxAmount = 7000
+			}
+			for _, line := range setups[tt.kind] {
+				if _, err := loadParser.ParseLoad(line); err != nil {
+					t.Fatalf("ParseLoad error %v", err)
+				}
+			}
+			probe := Load{LoadID: "2", CustomerID: "1", Amount: Amount{Value: 1}, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)}
+			got := loadParser.validateLoadAndFillHistory(probe)
+			if got.DayCount != tt.want.dayCount || got.DayAmount != tt.want.dayAmount {
+				t.Errorf("validateLoadAndFillHistory = %d loads and %v, want %v", got.DayCount, got.DayAmount, tt.want)
+			}
+		})
+	}
+}
+
+func Test_CountingRejectsNextLoad(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	loadParser.Policies[0].Counting.Rejected = LoadCounting{Count: true}
+	lines := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$100.00","time":"2020-01-06T09:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"3","customer_id":"1","load_amount":"$100.00","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"4","customer_id":"1","load_amount":"$100.00","time":"2020-01-06T12:00:00Z"}`,
+	}
+	want := []string{
+		`{"id":"1","customer_id":"1","accepted":true}`,
+		`{"id":"2","customer_id":"1","accepted":false}`,
+		`{"id":"3","customer_id":"1","accepted":true}`,
+		`{"id":"4","customer_id":"1","accepted":false}`,
+	}
+	for i, line := range lines {
+		if got, err := loadParser.ParseLoad(line); got != want[i] || err != nil {
+			t.Errorf("ParseLoad(%s) = %v and %v, want %v", line, got, err, want[i])
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package logic
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/jinzhu/now"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -11,39 +14,131 @@ const (
 	dayMaxAmount  = 5000
 	dayMaxCount   = 3
 	weekMaxAmount = 20000
+
+	// DayAmountRule name of the rule limiting the amount loaded per day
+	DayAmountRule = "day_amount"
+	// DayCountRule name of the rule limiting the number of loads per day
+	DayCountRule = "day_count"
+	// WeekAmountRule name of the rule limiting the amount loaded per week
+	WeekAmountRule = "week_amount"
+
+	// CustomerLevel limits applied to a single customer
+	CustomerLevel = "customer"
+	// GroupLevel limits shared by the customers of a group
+	GroupLevel = "group"
 )
 
-// inputLoad represents a inputLoad json input
-type inputLoad struct {
-	LoadID     string     `json:"id"`
-	CustomerID string     `json:"customer_id"`
-	Amount     loadAmount `json:"load_amount"`
-	Time       time.Time  `json:"time"`
+// RuleOutcome result of one limit for a load, value includes the load itself
+// the window is only given for rules over a rolling duration
+type RuleOutcome struct {
+	Rule   string  `json:"rule"`
+	Window string  `json:"window,omitempty"`
+	Limit  float64 `json:"limit"`
+	Value  float64 `json:"value"`
+	Passed bool    `json:"passed"`
+	Level  string  `json:"level"`
 }
 
-// loadResponse response given to a load
-type loadResponse struct {
-	LoadID     string `json:"id"`
-	CustomerID string `json:"customer_id"`
-	Accepted   bool   `json:"accepted"`
+// loadEvaluation evidence gathered while validating a load, sums and counts exclude the load
+type loadEvaluation struct {
+	Accepted        bool
+	Pending         bool
+	DayAmount       float64
+	DayCount        int
+	WeekAmount      float64
+	GroupID         string
+	Rules           []RuleOutcome
+	Breached        []string
+	Policy          Policy
+	LockedUntil     *time.Time
+	Partial         bool
+	Reserved        bool
+	RequestedAmount float64
+	AcceptedAmount  float64
+}
+
+// DecisionEvidence everything used to take the decision on a load
+type DecisionEvidence struct {
+	Input           json.RawMessage `json:"input"`
+	LoadID          string          `json:"id"`
+	CustomerID      string          `json:"customer_id"`
+	PolicyVersion   string          `json:"policy_version"`
+	GroupID         string          `json:"group_id,omitempty"`
+	DayAmount       float64         `json:"day_amount"`
+	DayCount        int             `json:"day_count"`
+	WeekAmount      float64         `json:"week_amount"`
+	Rules           []RuleOutcome   `json:"rules"`
+	Accepted        bool            `json:"accepted"`
+	Pending         bool            `json:"pending,omitempty"`
+	LockedUntil     *time.Time      `json:"locked_until,omitempty"`
+	RequestedAmount float64         `json:"requested_amount,omitempty"`
+	AcceptedAmount  float64         `json:"accepted_amount,omitempty"`
+}
+
+// DecisionAuditor receives the evidence of each decision taken
+type DecisionAuditor interface {
+	AuditDecision(evidence DecisionEvidence) error
+}
+
+// Load represents a load json input
+type Load struct {
+	LoadID     string    `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	Amount     Amount    `json:"load_amount"`
+	Time       time.Time `json:"time"`
+}
+
+// Decision response given to a load, a pending load is neither accepted nor rejected yet
+// breached levels are only given for customers belonging to a group, rejection reasons for loads failing a rule on
+// their own amount or of a locked customer, the end of the lock being given while the customer is locked
+// requested and accepted amounts are only given for loads accepted partially, the reservation for reserved loads and
+// their capture or release
+type Decision struct {
+	LoadID          string        `json:"id"`
+	CustomerID      string        `json:"customer_id"`
+	Accepted        bool          `json:"accepted"`
+	Pending         bool          `json:"pending,omitempty"`
+	Breached        []string      `json:"breached_levels,omitempty"`
+	Reasons         []string      `json:"rejection_reasons,omitempty"`
+	LockedUntil     *time.Time    `json:"locked_until,omitempty"`
+	RequestedAmount *Amount       `json:"requested_amount,omitempty"`
+	AcceptedAmount  *Amount       `json:"accepted_amount,omitempty"`
+	Reservation     string        `json:"reservation,omitempty"`
+	Rules           []RuleOutcome `json:"-"`
+	PolicyVersion   string        `json:"-"`
 }
 
 // customerLoadID couple load / customer
 type customerLoadID struct {
-	LoadID     string
-	CustomerID string
+	LoadID     string `json:"id"`
+	CustomerID string `json:"customer_id"`
 }
 
-// loadAmount represents the amount value of a load
-type loadAmount struct {
+// Amount represents the amount value of a load
+type Amount struct {
 	Value float64
 }
 
-// UnmarshalJSON implementation of parsing of $123.45 to a loadAmount
-func (l *loadAmount) UnmarshalJSON(b []byte) error {
-	amountStr := string(b)
-	numberAmountStr := amountStr[2 : len(amountStr)-1]
-	amount, err := strconv.ParseFloat(numberAmountStr, 64)
+// MarshalJSON implementation writing an Amount as $123.45, or -$123.45 when negative
+func (l Amount) MarshalJSON() ([]byte, error) {
+	value, sign := l.Value, ""
+	if value < 0 {
+		value, sign = -value, "-"
+	}
+	amountStr := strconv.FormatFloat(value, 'f', 2, 64)
+	if parsed, _ := strconv.ParseFloat(amountStr, 64); parsed != value {
+		amountStr = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return []byte(`"` + sign + `$` + amountStr + `"`), nil
+}
+
+// UnmarshalJSON implementation of parsing of $123.45 or -$123.45 to an Amount
+func (l *Amount) UnmarshalJSON(b []byte) error {
+	var amountStr string
+	if err := json.Unmarshal(b, &amountStr); err != nil {
+		return err
+	}
+	amount, err := parseAmount(amountStr)
 	if err != nil {
 		return err
 	}
@@ -53,8 +148,19 @@ func (l *loadAmount) UnmarshalJSON(b []byte) error {
 
 // FinanceLogic LoadParser implementation for holding history maps
 type FinanceLogic struct {
-	CustomersLoads map[string][]inputLoad
-	TreatedLoadIds map[customerLoadID]interface{}
+	CustomersLoads   map[string][]Load
+	TreatedLoadIds   map[customerLoadID]interface{}
+	HeldLoads        map[customerLoadID]Load
+	RejectedLoads    map[string][]Load
+	CountedLoads     map[string][]CountedLoad
+	Reservations     map[string][]Reservation
+	CustomerLocks    map[string]CustomerLock
+	Groups           CustomerGroups
+	customerGroups   map[string]string
+	Policies         PolicyHistory
+	Auditor          DecisionAuditor
+	previousPolicies []PolicyHistory
+	mutex            sync.Mutex
 }
 
 // LoadParser interface for defining how to parse loads
@@ -62,29 +168,151 @@ type LoadParser interface {
 	ParseLoads(parsingChannel chan string) ([]string, []error)
 }
 
-// NewFinanceLogic creates a LoadParser implementation
+// Engine interface for validating typed loads, safe for concurrent use
+type Engine interface {
+	Validate(ctx context.Context, load Load) (Decision, error)
+}
+
+// ErrDuplicateLoad returned when validating a load whose (id, customer id) couple was already treated
+var ErrDuplicateLoad = errors.New("load already treated")
+
+// NewFinanceLogic creates a LoadParser and Engine implementation
 func NewFinanceLogic() *FinanceLogic {
 	return &FinanceLogic{
-		CustomersLoads: make(map[string][]inputLoad),
+		CustomersLoads: make(map[string][]Load),
 		TreatedLoadIds: make(map[customerLoadID]interface{}),
+		HeldLoads:      make(map[customerLoadID]Load),
+		RejectedLoads:  make(map[string][]Load),
+		CountedLoads:   make(map[string][]CountedLoad),
+		Reservations:   make(map[string][]Reservation),
+		CustomerLocks:  make(map[string]CustomerLock),
+		Groups:         make(CustomerGroups),
+		customerGroups: make(map[string]string),
+		Policies:       PolicyHistory{DefaultPolicy()},
 	}
 }
 
 // validateLoadAndFillHistory deals with load history for each customer and validate
-func (logic *FinanceLogic) validateLoadAndFillHistory(load inputLoad) bool {
+func (logic *FinanceLogic) validateLoadAndFillHistory(load Load) loadEvaluation {
+	return logic.validateLoadAndFillHistoryAs(load, false)
+}
+
+// validateLoadAndFillHistoryAs validates a load, an accepted load being reserved rather than added to the history
+// when asked
+func (logic *FinanceLogic) validateLoadAndFillHistoryAs(load Load, reserve bool) loadEvaluation {
 	customerLoads, customerExist := logic.CustomersLoads[load.CustomerID]
 	if !customerExist {
-		customerLoads = make([]inputLoad, 0)
+		customerLoads = make([]Load, 0)
+	}
+	policy := logic.Policies.At(load.Time)
+	logic.expireReservations(load.CustomerID, load.Time)
+	evaluation := logic.evaluate(load, customerLoads, policy)
+	if _, locked := logic.lockedAt(load.CustomerID, load.Time); policy.PartialAcceptance && !locked {
+		load, evaluation = logic.acceptPartially(load, customerLoads, evaluation)
+	}
+	logic.applyLockout(load, &evaluation)
+	if !evaluation.Accepted && !evaluation.Pending {
+		logic.recordCounted(load, RejectedLoadKind, policy)
 	}
-	validated := validateLoad(load, customerLoads)
-	if validated {
+	if evaluation.Accepted && reserve {
+		logic.reserve(load, policy)
+		evaluation.Reserved = true
+	} else if evaluation.Accepted {
 		logic.CustomersLoads[load.CustomerID] = append(customerLoads, load)
 	}
-	return validated
+	if evaluation.Pending {
+		logic.HeldLoads[customerLoadID{LoadID: load.LoadID, CustomerID: load.CustomerID}] = load
+	}
+	return evaluation
 }
 
-// validateLoad validates a load using load history given as parameter
-func validateLoad(load inputLoad, customerLoads []inputLoad) bool {
+// evaluate validates a load against the history of its customer and of its group
+func (logic *FinanceLogic) evaluate(load Load, customerLoads []Load, policy Policy) loadEvaluation {
+	countLoads, amountLoads := logic.windowLoads([]string{load.CustomerID}, customerLoads, policy, load.Time)
+	evaluation := validateLoadWindows(load, countLoads, amountLoads, policy)
+	if groupID, grouped := logic.customerGroups[load.CustomerID]; grouped {
+		evaluation.GroupID = groupID
+		groupCountLoads, groupAmountLoads := logic.windowLoads(logic.Groups[groupID], logic.groupLoads(groupID), policy, load.Time)
+		evaluation.Rules = append(evaluation.Rules, validateGroupLoad(load, groupCountLoads, groupAmountLoads, policy)...)
+		evaluation.decide()
+	}
+	return evaluation
+}
+
+// validateLoad validates a load using load history given as parameter and the policy effective at the time of the load
+func validateLoad(load Load, customerLoads []Load, policies PolicyHistory) loadEvaluation {
+	return validateLoadWindows(load, customerLoads, customerLoads, policies.At(load.Time))
+}
+
+// validateLoadWindows validates a load with the loads counting in the number of loads and in the amount of the windows
+func validateLoadWindows(load Load, countLoads []Load, amountLoads []Load, policy Policy) loadEvaluation {
+	evaluation := loadEvaluation{Policy: policy}
+	evaluation.DayAmount, evaluation.DayCount, evaluation.WeekAmount = countedUsage(load, countLoads, amountLoads)
+	evaluation.Rules = []RuleOutcome{
+		{
+			Rule:   DayAmountRule,
+			Limit:  policy.DayMaxAmount,
+			Value:  evaluation.DayAmount + load.Amount.Value,
+			Passed: evaluation.DayAmount+load.Amount.Value <= policy.DayMaxAmount,
+			Level:  CustomerLevel,
+		},
+		{
+			Rule:   DayCountRule,
+			Limit:  float64(policy.DayMaxCount),
+			Value:  float64(evaluation.DayCount + 1),
+			Passed: evaluation.DayCount < policy.DayMaxCount,
+			Level:  CustomerLevel,
+		},
+		{
+			Rule:   WeekAmountRule,
+			Limit:  policy.WeekMaxAmount,
+			Value:  evaluation.WeekAmount + load.Amount.Value,
+			Passed: evaluation.WeekAmount+load.Amount.Value <= policy.WeekMaxAmount,
+			Level:  CustomerLevel,
+		},
+	}
+	evaluation.Rules = append(evaluation.Rules, validateBurstLoad(load, countLoads, amountLoads, policy)...)
+	evaluation.Rules = append(evaluation.Rules, validateLoadAmount(load, policy)...)
+	evaluation.decide()
+	return evaluation
+}
+
+// validateGroupLoad gives the outcome of the group limits set in the policy using the loads of the whole group
+func validateGroupLoad(load Load, countLoads []Load, amountLoads []Load, policy Policy) []RuleOutcome {
+	dayAmount, dayCount, weekAmount := countedUsage(load, countLoads, amountLoads)
+	outcomes := make([]RuleOutcome, 0)
+	if policy.GroupDayMaxAmount != 0 {
+		outcomes = append(outcomes, RuleOutcome{
+			Rule:   DayAmountRule,
+			Limit:  policy.GroupDayMaxAmount,
+			Value:  dayAmount + load.Amount.Value,
+			Passed: dayAmount+load.Amount.Value <= policy.GroupDayMaxAmount,
+			Level:  GroupLevel,
+		})
+	}
+	if policy.GroupDayMaxCount != 0 {
+		outcomes = append(outcomes, RuleOutcome{
+			Rule:   DayCountRule,
+			Limit:  float64(policy.GroupDayMaxCount),
+			Value:  float64(dayCount + 1),
+			Passed: dayCount < policy.GroupDayMaxCount,
+			Level:  GroupLevel,
+		})
+	}
+	if policy.GroupWeekMaxAmount != 0 {
+		outcomes = append(outcomes, RuleOutcome{
+			Rule:   WeekAmountRule,
+			Limit:  policy.GroupWeekMaxAmount,
+			Value:  weekAmount + load.Amount.Value,
+			Passed: weekAmount+load.Amount.Value <= policy.GroupWeekMaxAmount,
+			Level:  GroupLevel,
+		})
+	}
+	return outcomes
+}
+
+// windowUsage sums amounts and counts loads in the day and week of a load
+func windowUsage(load Load, loads []Load) (float64, int, float64) {
 	dayStart := now.With(load.Time).BeginningOfDay().Add(-time.Second) // removing one second for comparison
 	dayEnd := now.With(load.Time).EndOfDay()
 	weekEnd := now.With(load.Time).EndOfWeek()
@@ -92,7 +320,7 @@ func validateLoad(load inputLoad, customerLoads []inputLoad) bool {
 	dayAmountSum := float64(0)
 	dayAmountCount := 0
 	weekAmountSum := float64(0)
-	for _, storedLoad := range customerLoads {
+	for _, storedLoad := range loads {
 		if storedLoad.Time.After(dayStart) && storedLoad.Time.Before(dayEnd) {
 			dayAmountCount++
 			dayAmountSum += storedLoad.Amount.Value
@@ -101,51 +329,162 @@ func validateLoad(load inputLoad, customerLoads []inputLoad) bool {
 			weekAmountSum += storedLoad.Amount.Value
 		}
 	}
+	return dayAmountSum, dayAmountCount, weekAmountSum
+}
 
-	if dayAmountSum+load.Amount.Value > dayMaxAmount {
-		return false
+// countedUsage sums amounts of the amount loads and counts the count loads in the day and week of a load
+func countedUsage(load Load, countLoads []Load, amountLoads []Load) (float64, int, float64) {
+	dayAmount, dayCount, weekAmount := windowUsage(load, amountLoads)
+	if !sameLoads(countLoads, amountLoads) {
+		_, dayCount, _ = windowUsage(load, countLoads)
 	}
-	if dayAmountCount >= dayMaxCount {
-		return false
+	return dayAmount, dayCount, weekAmount
+}
+
+// decide accepts the load if every rule passed, holds it if every failed rule can be reviewed
+func (evaluation *loadEvaluation) decide() {
+	evaluation.Accepted = true
+	evaluation.Breached = nil
+	reviewable := true
+	for _, outcome := range evaluation.Rules {
+		evaluation.Accepted = evaluation.Accepted && outcome.Passed
+		reviewable = reviewable && (outcome.Passed || evaluation.Policy.reviewable(outcome))
+		if !outcome.Passed && !containsLevel(evaluation.Breached, outcome.Level) {
+			evaluation.Breached = append(evaluation.Breached, outcome.Level)
+		}
 	}
-	if weekAmountSum+load.Amount.Value > weekMaxAmount {
-		return false
+	evaluation.Pending = !evaluation.Accepted && reviewable
+}
+
+// containsLevel tells if a level is in a list of levels
+func containsLevel(levels []string, level string) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
 	}
-	return true
+	return false
 }
 
 // ParseLoads parse the loads given in a channel
 func (logic *FinanceLogic) ParseLoads(parsingChannel chan string) ([]string, []error) {
+	loadResponses, loadErrors, _ := logic.ParseLoadsContext(context.Background(), parsingChannel)
+	return loadResponses, loadErrors
+}
+
+// ParseLoadsContext parse the loads given in a channel until it is closed or the context is done
+// it gives the responses and errors of the lines parsed so far and the number of lines parsed
+func (logic *FinanceLogic) ParseLoadsContext(ctx context.Context, parsingChannel chan string) ([]string, []error, int) {
 	loadResponses := make([]string, 0)
 	loadErrors := make([]error, 0)
-	for line := range parsingChannel {
-		var loadTry inputLoad
-		err := json.Unmarshal([]byte(line), &loadTry)
+	parsedLines := logic.ParseLoadsEach(ctx, parsingChannel, func(line string, loadResponse string, err error) {
 		if err != nil {
 			loadErrors = append(loadErrors, err)
-		} else {
-			if logic.addCustomerLoadToTreated(loadTry) { // do not treat if (loadid, customerid)  couple already exists
-				loadStatus := logic.validateLoadAndFillHistory(loadTry)
-				loadResponse := loadResponse{
-					LoadID:     loadTry.LoadID,
-					CustomerID: loadTry.CustomerID,
-					Accepted:   loadStatus,
-				}
-				loadResponseString, err := json.Marshal(loadResponse)
-				if err != nil {
-					loadErrors = append(loadErrors, err)
-				} else {
-					loadResponses = append(loadResponses, string(loadResponseString))
-				}
+		}
+		if loadResponse != "" {
+			loadResponses = append(loadResponses, loadResponse)
+		}
+	})
+	return loadResponses, loadErrors, parsedLines
+}
+
+// ParseLoadsEach parse the loads given in a channel until it is closed or the context is done, giving each line with
+// its response and error to handle as it is parsed
+// it gives the number of lines parsed
+func (logic *FinanceLogic) ParseLoadsEach(ctx context.Context, parsingChannel chan string, handle func(line string, loadResponse string, err error)) int {
+	parsedLines := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return parsedLines
+		case line, open := <-parsingChannel:
+			if !open {
+				return parsedLines
 			}
+			loadResponse, err := logic.ParseLoad(line)
+			handle(line, loadResponse, err)
+			parsedLines++
 		}
+	}
+}
 
+// ParseLoad parse one load and gives its response, an empty response if the load was already treated or not parsable
+// a response can come with an error when the decision was taken but not audited
+// a line with a type reserves a load, captures or releases a reserved one
+func (logic *FinanceLogic) ParseLoad(line string) (string, error) {
+	var loadTry struct {
+		Load
+		Type string `json:"type"`
 	}
-	return loadResponses, loadErrors
+	err := json.Unmarshal([]byte(line), &loadTry)
+	if err != nil {
+		return "", err
+	}
+	var decision Decision
+	var validationErr error
+	switch loadTry.Type {
+	case "":
+		decision, validationErr = logic.validate(loadTry.Load, json.RawMessage(line), false)
+	case ReserveEvent:
+		decision, validationErr = logic.validate(loadTry.Load, json.RawMessage(line), true)
+	default:
+		if decision, err = logic.parseReservationEvent(loadTry.Type, loadTry.Load); err != nil {
+			return "", err
+		}
+	}
+	if validationErr == ErrDuplicateLoad { // do not treat if (loadid, customerid)  couple already exists
+		return "", nil
+	}
+	loadResponseString, err := json.Marshal(decision)
+	if err != nil {
+		return "", err
+	}
+	return string(loadResponseString), validationErr
+}
+
+// Validate Engine implementation deciding on a load and adding it to the history when accepted
+// a decision can come with an error when it was taken but not audited
+func (logic *FinanceLogic) Validate(ctx context.Context, load Load) (Decision, error) {
+	if err := ctx.Err(); err != nil {
+		return Decision{}, err
+	}
+	return logic.validate(load, nil, false)
+}
+
+// validate decides on a load, input being the payload given to the auditor, reserving it if accepted when asked
+func (logic *FinanceLogic) validate(load Load, input json.RawMessage, reserve bool) (Decision, error) {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	if !logic.addCustomerLoadToTreated(load) {
+		logic.recordCounted(load, DuplicateLoadKind, logic.Policies.At(load.Time))
+		return Decision{}, ErrDuplicateLoad
+	}
+	evaluation := logic.validateLoadAndFillHistoryAs(load, reserve)
+	decision := Decision{
+		LoadID:        load.LoadID,
+		CustomerID:    load.CustomerID,
+		Accepted:      evaluation.Accepted,
+		Pending:       evaluation.Pending,
+		Reasons:       rejectionReasons(evaluation.Rules),
+		LockedUntil:   evaluation.LockedUntil,
+		Rules:         evaluation.Rules,
+		PolicyVersion: evaluation.Policy.Version,
+	}
+	if evaluation.GroupID != "" {
+		decision.Breached = evaluation.Breached
+	}
+	if evaluation.Reserved {
+		decision.Reservation = ReservedStatus
+	}
+	if evaluation.Partial {
+		decision.RequestedAmount = &Amount{Value: evaluation.RequestedAmount}
+		decision.AcceptedAmount = &Amount{Value: evaluation.AcceptedAmount}
+	}
+	return decision, logic.audit(input, load, evaluation)
 }
 
 // addCustomerLoadToTreated adds load to the list of treated ones and returns false if not added (already exists)
-func (logic *FinanceLogic) addCustomerLoadToTreated(load inputLoad) bool {
+func (logic *FinanceLogic) addCustomerLoadToTreated(load Load) bool {
 	customerLoadID := customerLoadID{
 		LoadID:     load.LoadID,
 		CustomerID: load.CustomerID,
@@ -157,3 +496,32 @@ func (logic *FinanceLogic) addCustomerLoadToTreated(load inputLoad) bool {
 	logic.TreatedLoadIds[customerLoadID] = nil
 	return true
 }
+
+// audit gives the evidence of a decision to the auditor if there is one, the load is the input if none given
+func (logic *FinanceLogic) audit(input json.RawMessage, load Load, evaluation loadEvaluation) error {
+	if logic.Auditor == nil {
+		return nil
+	}
+	if input == nil {
+		var err error
+		if input, err = json.Marshal(load); err != nil {
+			return err
+		}
+	}
+	return logic.Auditor.AuditDecision(DecisionEvidence{
+		Input:           input,
+		LoadID:          load.LoadID,
+		CustomerID:      load.CustomerID,
+		PolicyVersion:   evaluation.Policy.Version,
+		GroupID:         evaluation.GroupID,
+		DayAmount:       evaluation.DayAmount,
+		DayCount:        evaluation.DayCount,
+		WeekAmount:      evaluation.WeekAmount,
+		Rules:           evaluation.Rules,
+		Accepted:        evaluation.Accepted,
+		Pending:         evaluation.Pending,
+		LockedUntil:     evaluation.LockedUntil,
+		RequestedAmount: evaluation.RequestedAmount,
+		AcceptedAmount:  evaluation.AcceptedAmount,
+	})
+}
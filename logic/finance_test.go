@@ -1,8 +1,10 @@
 package logic
 
 import (
+	"context"
 	"encoding/json"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -12,7 +14,7 @@ func Test_UnmarshalJSON(t *testing.T) {
 		jsonString string
 	}
 	type output struct {
-		load     inputLoad
+		load     Load
 		hasError bool
 	}
 	tests := []struct {
@@ -26,10 +28,10 @@ func Test_UnmarshalJSON(t *testing.T) {
 				jsonString: `{"id": "1234","customer_id": "2345","load_amount": "$123.45","time": "2018-01-01T00:00:00Z"}`,
 			},
 			want: output{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1234",
 					CustomerID: "2345",
-					Amount:     loadAmount{Value: 123.45},
+					Amount:     Amount{Value: 123.45},
 					Time:       time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
 				},
 				hasError: false,
@@ -41,11 +43,11 @@ func Test_UnmarshalJSON(t *testing.T) {
 				jsonString: `{"id": "1234","customer_id": "2345","load_amount": "AAAAAAAAAA","time": "2018-01-01T00:00:00Z"}`,
 			},
 			want: output{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1234",
 					CustomerID: "2345",
-					Amount:     loadAmount{Value: 0},
-					Time:       time.Date(0001, time.January, 1, 0, 0, 0, 0, time.UTC), // time not parsed because of error in amount
+					Amount:     Amount{Value: 0},
+					Time:       time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC), // time still parsed despite the error in amount
 				},
 				hasError: true,
 			},
@@ -54,7 +56,7 @@ func Test_UnmarshalJSON(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := inputLoad{}
+			l := Load{}
 			err := json.Unmarshal([]byte(tt.args.jsonString), &l)
 			if (err != nil) != tt.want.hasError || l != tt.want.load {
 				t.Errorf("unmarshallJson = %v and %v, want %v", l, err, tt.want)
@@ -65,8 +67,8 @@ func Test_UnmarshalJSON(t *testing.T) {
 
 func Test_validateLoad(t *testing.T) {
 	type args struct {
-		load         inputLoad
-		historyLoads []inputLoad
+		load         Load
+		historyLoads []Load
 	}
 	tests := []struct {
 		name string
@@ -76,10 +78,10 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateAcceptedNoHistory",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
 				historyLoads: nil,
@@ -89,10 +91,10 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateRefusedMaxAmountNoHistory",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 6000},
+					Amount:     Amount{Value: 6000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
 				historyLoads: nil,
@@ -102,17 +104,17 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMaxAmountDifferentDays",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "2",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 2, 10, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 					},
 				},
@@ -122,17 +124,17 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMaxAmountDay",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "2",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 					},
 				},
@@ -142,17 +144,17 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateExactMaxAmountDay",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "2",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 2000},
+						Amount:     Amount{Value: 2000},
 						Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 					},
 				},
@@ -162,29 +164,29 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMaxCountDay",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "4",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 1000},
+					Amount:     Amount{Value: 1000},
 					Time:       time.Date(2000, time.Month(1), 1, 15, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 11, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "3",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 12, 0, 0, 0, time.UTC),
 					},
 				},
@@ -194,23 +196,23 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateExactCountDay",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "4",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 1000},
+					Amount:     Amount{Value: 1000},
 					Time:       time.Date(2000, time.Month(1), 1, 15, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 11, 0, 0, 0, time.UTC),
 					},
 				},
@@ -220,29 +222,29 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMaxCountOnDifferentDays",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "4",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 1000},
+					Amount:     Amount{Value: 1000},
 					Time:       time.Date(2000, time.Month(1), 2, 15, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 11, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "3",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 1000},
+						Amount:     Amount{Value: 1000},
 						Time:       time.Date(2000, time.Month(1), 1, 12, 0, 0, 0, time.UTC),
 					},
 				},
@@ -252,29 +254,29 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateExactMaxOnWeek",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "4",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 5000},
+					Amount:     Amount{Value: 5000},
 					Time:       time.Date(2020, time.Month(1), 9, 15, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 6, 10, 0, 0, 0, time.UTC), // 6th Jan 2020 is a Monday
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 7, 11, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "3",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 8, 12, 0, 0, 0, time.UTC),
 					},
 				},
@@ -284,35 +286,35 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMaxOnWeek",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "5",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 4000},
+					Amount:     Amount{Value: 4000},
 					Time:       time.Date(2020, time.Month(1), 10, 15, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 6, 10, 0, 0, 0, time.UTC), // 6 Jan is a Monday
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 7, 11, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "3",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 8, 12, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "4",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2020, time.Month(1), 9, 12, 0, 0, 0, time.UTC),
 					},
 				},
@@ -322,35 +324,35 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMaxOnTwoWeeks",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "5",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 4000},
+					Amount:     Amount{Value: 4000},
 					Time:       time.Date(2020, time.Month(1), 13, 15, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 6, 10, 0, 0, 0, time.UTC), // 6 Jan is a Monday
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 7, 11, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "3",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 8, 12, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "4",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2020, time.Month(1), 9, 12, 0, 0, 0, time.UTC),
 					},
 				},
@@ -360,35 +362,35 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMidnightOnWeeks",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "5",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 4000},
+					Amount:     Amount{Value: 4000},
 					Time:       time.Date(2020, time.Month(1), 12, 23, 59, 59, 0, time.UTC).Add(time.Second),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 6, 10, 0, 0, 0, time.UTC), // 6 Jan is a Monday
 					},
-					inputLoad{
+					Load{
 						LoadID:     "2",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 7, 11, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "3",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 5000},
+						Amount:     Amount{Value: 5000},
 						Time:       time.Date(2020, time.Month(1), 8, 12, 0, 0, 0, time.UTC),
 					},
-					inputLoad{
+					Load{
 						LoadID:     "4",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2020, time.Month(1), 9, 12, 0, 0, 0, time.UTC),
 					},
 				},
@@ -398,17 +400,17 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMidnightOnTwoDays",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "5",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 4000},
+					Amount:     Amount{Value: 4000},
 					Time:       time.Date(2020, time.Month(1), 7, 0, 0, 0, 0, time.UTC),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2020, time.Month(1), 6, 10, 0, 0, 0, time.UTC), // 6 Jan is a Monday
 					},
 				},
@@ -418,17 +420,17 @@ func Test_validateLoad(t *testing.T) {
 		{
 			"validateMidnightMinusOneSecond",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "5",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 4000},
+					Amount:     Amount{Value: 4000},
 					Time:       time.Date(2020, time.Month(1), 7, 0, 0, 0, 0, time.UTC).Add(-time.Second),
 				},
-				historyLoads: []inputLoad{
-					inputLoad{
+				historyLoads: []Load{
+					Load{
 						LoadID:     "1",
 						CustomerID: "1",
-						Amount:     loadAmount{Value: 3000},
+						Amount:     Amount{Value: 3000},
 						Time:       time.Date(2020, time.Month(1), 6, 10, 0, 0, 0, time.UTC), // 6 Jan is a Monday
 					},
 				},
@@ -439,7 +441,7 @@ func Test_validateLoad(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := validateLoad(tt.args.load, tt.args.historyLoads); got != tt.want {
+			if got := validateLoad(tt.args.load, tt.args.historyLoads, PolicyHistory{DefaultPolicy()}).Accepted; got != tt.want {
 				t.Errorf("validateLoad = %v, want %v", got, tt.want)
 			}
 		})
@@ -448,12 +450,12 @@ func Test_validateLoad(t *testing.T) {
 
 func Test_validateLoadAndFillHistory(t *testing.T) {
 	type args struct {
-		load                 inputLoad
-		customerHistoryLoads map[string][]inputLoad
+		load                 Load
+		customerHistoryLoads map[string][]Load
 	}
 	type output struct {
 		returnedValue        bool
-		customerHistoryLoads map[string][]inputLoad
+		customerHistoryLoads map[string][]Load
 	}
 	tests := []struct {
 		name string
@@ -463,22 +465,22 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 		{
 			"validateEmptyAccepted",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
-				customerHistoryLoads: make(map[string][]inputLoad),
+				customerHistoryLoads: make(map[string][]Load),
 			},
 			output{
 				returnedValue: true,
-				customerHistoryLoads: map[string][]inputLoad{
+				customerHistoryLoads: map[string][]Load{
 					"1": {
-						inputLoad{
+						Load{
 							LoadID:     "1",
 							CustomerID: "1",
-							Amount:     loadAmount{Value: 3000},
+							Amount:     Amount{Value: 3000},
 							Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 						}},
 				},
@@ -487,34 +489,34 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 		{
 			"validateEmptyNotAccepted",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 6000},
+					Amount:     Amount{Value: 6000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
-				customerHistoryLoads: make(map[string][]inputLoad),
+				customerHistoryLoads: make(map[string][]Load),
 			},
 			output{
 				returnedValue:        false,
-				customerHistoryLoads: make(map[string][]inputLoad),
+				customerHistoryLoads: make(map[string][]Load),
 			},
 		},
 		{
 			"validateNotEmptyAccepted",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
-				customerHistoryLoads: map[string][]inputLoad{
+				customerHistoryLoads: map[string][]Load{
 					"1": {
-						inputLoad{
+						Load{
 							LoadID:     "2",
 							CustomerID: "1",
-							Amount:     loadAmount{Value: 1000},
+							Amount:     Amount{Value: 1000},
 							Time:       time.Date(2000, time.Month(1), 1, 5, 0, 0, 0, time.UTC),
 						},
 					},
@@ -522,18 +524,18 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 			},
 			output{
 				returnedValue: true,
-				customerHistoryLoads: map[string][]inputLoad{
+				customerHistoryLoads: map[string][]Load{
 					"1": {
-						inputLoad{
+						Load{
 							LoadID:     "2",
 							CustomerID: "1",
-							Amount:     loadAmount{Value: 1000},
+							Amount:     Amount{Value: 1000},
 							Time:       time.Date(2000, time.Month(1), 1, 5, 0, 0, 0, time.UTC),
 						},
-						inputLoad{
+						Load{
 							LoadID:     "1",
 							CustomerID: "1",
-							Amount:     loadAmount{Value: 3000},
+							Amount:     Amount{Value: 3000},
 							Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 						}},
 				},
@@ -542,18 +544,18 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 		{
 			"validateNotEmptyNotAccepted",
 			args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{Value: 3000},
+					Amount:     Amount{Value: 3000},
 					Time:       time.Date(2000, time.Month(1), 1, 10, 0, 0, 0, time.UTC),
 				},
-				customerHistoryLoads: map[string][]inputLoad{
+				customerHistoryLoads: map[string][]Load{
 					"1": {
-						inputLoad{
+						Load{
 							LoadID:     "2",
 							CustomerID: "1",
-							Amount:     loadAmount{Value: 3000},
+							Amount:     Amount{Value: 3000},
 							Time:       time.Date(2000, time.Month(1), 1, 5, 0, 0, 0, time.UTC),
 						},
 					},
@@ -561,12 +563,12 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 			},
 			output{
 				returnedValue: false,
-				customerHistoryLoads: map[string][]inputLoad{
+				customerHistoryLoads: map[string][]Load{
 					"1": {
-						inputLoad{
+						Load{
 							LoadID:     "2",
 							CustomerID: "1",
-							Amount:     loadAmount{Value: 3000},
+							Amount:     Amount{Value: 3000},
 							Time:       time.Date(2000, time.Month(1), 1, 5, 0, 0, 0, time.UTC),
 						}},
 				},
@@ -578,7 +580,7 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			loadParser := NewFinanceLogic()
 			loadParser.CustomersLoads = tt.args.customerHistoryLoads
-			if got := loadParser.validateLoadAndFillHistory(tt.args.load); got != tt.want.returnedValue || !reflect.DeepEqual(loadParser.CustomersLoads, tt.want.customerHistoryLoads) {
+			if got := loadParser.validateLoadAndFillHistory(tt.args.load).Accepted; got != tt.want.returnedValue || !reflect.DeepEqual(loadParser.CustomersLoads, tt.want.customerHistoryLoads) {
 				t.Errorf("validateLoadAndFillHistory = %v and %v, want %v", got, loadParser.CustomersLoads, tt.want)
 			}
 		})
@@ -587,7 +589,7 @@ func Test_validateLoadAndFillHistory(t *testing.T) {
 
 func Test_addCustomerLoadToTreated(t *testing.T) {
 	type args struct {
-		load           inputLoad
+		load           Load
 		treatedLoadIds map[customerLoadID]interface{}
 	}
 
@@ -599,10 +601,10 @@ func Test_addCustomerLoadToTreated(t *testing.T) {
 		{
 			name: "NewCustomerLoad",
 			args: args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{},
+					Amount:     Amount{},
 					Time:       time.Time{},
 				},
 				treatedLoadIds: make(map[customerLoadID]interface{}),
@@ -612,10 +614,10 @@ func Test_addCustomerLoadToTreated(t *testing.T) {
 		{
 			name: "ExistingCustomerLoad",
 			args: args{
-				load: inputLoad{
+				load: Load{
 					LoadID:     "1",
 					CustomerID: "1",
-					Amount:     loadAmount{},
+					Amount:     Amount{},
 					Time:       time.Time{},
 				},
 				treatedLoadIds: map[customerLoadID]interface{}{
@@ -672,6 +674,18 @@ func Test_ParseLoads(t *testing.T) {
 				loadResponses:  make([]string, 0),
 				numberOfErrors: 0,
 			}},
+		{
+			name: "duplicateLoad",
+			args: args{
+				loadStrings: []string{
+					`{"id": "1234","customer_id": "2345","load_amount": "$123.45","time": "2018-01-01T00:00:00Z"}`,
+					`{"id": "1234","customer_id": "2345","load_amount": "$123.45","time": "2018-01-01T00:00:00Z"}`,
+				},
+			},
+			want: output{
+				loadResponses:  []string{`{"id":"1234","customer_id":"2345","accepted":true}`},
+				numberOfErrors: 0,
+			}},
 		{
 			name: "jsonError",
 			args: args{
@@ -699,3 +713,175 @@ func Test_ParseLoads(t *testing.T) {
 		})
 	}
 }
+
+// evidenceRecorder DecisionAuditor keeping the evidences received
+type evidenceRecorder struct {
+	evidences []DecisionEvidence
+}
+
+// AuditDecision keeps the evidence
+func (r *evidenceRecorder) AuditDecision(evidence DecisionEvidence) error {
+	r.evidences = append(r.evidences, evidence)
+	return nil
+}
+
+func Test_Validate(t *testing.T) {
+	type output struct {
+		decision Decision
+		err      error
+	}
+	tests := []struct {
+		name    string
+		context func() context.Context
+		load    Load
+		want    output
+	}{
+		{
+			name:    "accepted",
+			context: context.Background,
+			load:    Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 1000}, Time: time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)},
+			want: output{
+				decision: Decision{LoadID: "3", CustomerID: "1", Accepted: true, PolicyVersion: "1"},
+				err:      nil,
+			},
+		},
+		{
+			name:    "rejected",
+			context: context.Background,
+			load:    Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 1500}, Time: time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)},
+			want: output{
+				decision: Decision{LoadID: "3", CustomerID: "1", Accepted: false, PolicyVersion: "1"},
+				err:      nil,
+			},
+		},
+		{
+			name:    "duplicate",
+			context: context.Background,
+			load:    Load{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 10}, Time: time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)},
+			want: output{
+				decision: Decision{},
+				err:      ErrDuplicateLoad,
+			},
+		},
+		{
+			name: "cancelled",
+			context: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			load: Load{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 1000}, Time: time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)},
+			want: output{
+				decision: Decision{},
+				err:      context.Canceled,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var engine Engine = NewFinanceLogic()
+			history := []Load{
+				{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 2000}, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)},
+				{LoadID: "2", CustomerID: "1", Amount: Amount{Value: 2000}, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)},
+			}
+			for _, load := range history {
+				if _, err := engine.Validate(context.Background(), load); err != nil {
+					t.Fatalf("Validate error %v", err)
+				}
+			}
+			got, err := engine.Validate(tt.context(), tt.load)
+			got.Rules = nil
+			if !reflect.DeepEqual(got, tt.want.decision) || err != tt.want.err {
+				t.Errorf("Validate = %v and %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateAuditInput(t *testing.T) {
+	recorder := &evidenceRecorder{}
+	loadParser := NewFinanceLogic()
+	loadParser.Auditor = recorder
+	load := Load{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 123.45}, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)}
+	if _, err := loadParser.Validate(context.Background(), load); err != nil {
+		t.Fatalf("Validate error %v", err)
+	}
+	if len(recorder.evidences) != 1 {
+		t.Fatalf("audited %d decisions, want 1", len(recorder.evidences))
+	}
+	var audited Load
+	if err := json.Unmarshal(recorder.evidences[0].Input, &audited); err != nil || audited != load {
+		t.Errorf("audited input %s gives %v and %v, want %v", recorder.evidences[0].Input, audited, err, load)
+	}
+}
+
+func Test_ValidateConcurrent(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	var wg sync.WaitGroup
+	accepted := make(chan bool, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			load := Load{LoadID: string(rune('a' + i)), CustomerID: "1", Amount: Amount{Value: 100}, Time: time.Date(2020, time.January, 6, 10, i, 0, 0, time.UTC)}
+			decision, err := loadParser.Validate(context.Background(), load)
+			if err != nil {
+				t.Errorf("Validate error %v", err)
+			}
+			accepted <- decision.Accepted
+		}(i)
+	}
+	wg.Wait()
+	close(accepted)
+	count := 0
+	for decision := range accepted {
+		if decision {
+			count++
+		}
+	}
+	if count != dayMaxCount {
+		t.Errorf("%d loads accepted, want %d", count, dayMaxCount)
+	}
+}
+
+func Test_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		want   string
+	}{
+		{name: "cents", amount: Amount{Value: 123.45}, want: `"$123.45"`},
+		{name: "noCents", amount: Amount{Value: 5000}, want: `"$5000.00"`},
+		{name: "moreDecimals", amount: Amount{Value: 0.125}, want: `"$0.125"`},
+		{name: "negative", amount: Amount{Value: -5}, want: `"-$5.00"`},
+		{name: "negativeMoreDecimals", amount: Amount{Value: -0.125}, want: `"-$0.125"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.amount)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("MarshalJSON = %s and %v, want %s", got, err, tt.want)
+			}
+			var parsed Amount
+			if err := json.Unmarshal(got, &parsed); err != nil || parsed != tt.amount {
+				t.Errorf("UnmarshalJSON = %v and %v, want %v", parsed, err, tt.amount)
+			}
+		})
+	}
+}
+
+func Test_ParseLoadsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	loadParser := NewFinanceLogic()
+	stringChannel := make(chan string)
+	go func() {
+		stringChannel <- `{"id": "1","customer_id": "1","load_amount": "$10.00","time": "2018-01-01T00:00:00Z"}`
+		stringChannel <- `{"id": "2","customer_id": "1","load_amount": "$10.00","time": "2018-01-01T01:00:00Z"}`
+		cancel()
+	}()
+	gotLoads, gotErrors, gotLines := loadParser.ParseLoadsContext(ctx, stringChannel)
+	want := []string{`{"id":"1","customer_id":"1","accepted":true}`, `{"id":"2","customer_id":"1","accepted":true}`}
+	if !reflect.DeepEqual(gotLoads, want) || len(gotErrors) != 0 || gotLines != 2 {
+		t.Errorf("ParseLoadsContext = %v, %v and %d lines, want %v", gotLoads, gotErrors, gotLines, want)
+	}
+}
@@ -0,0 +1,51 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// CustomerGroups customer ids of each household or legal entity, by group id
+type CustomerGroups map[string][]string
+
+// ReadGroups reads a json file mapping each group id to its customer ids
+func ReadGroups(filename string) (CustomerGroups, error) {
+	groups := make(CustomerGroups)
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return groups, err
+	}
+	err = json.Unmarshal(content, &groups)
+	return groups, err
+}
+
+// SetGroups sets the groups of customers sharing limits, a customer can only belong to one group
+func (logic *FinanceLogic) SetGroups(groups CustomerGroups) error {
+	customerGroups := make(map[string]string)
+	for groupID, customerIDs := range groups {
+		for _, customerID := range customerIDs {
+			if otherGroupID, exist := customerGroups[customerID]; exist && otherGroupID != groupID {
+				return fmt.Errorf("customer %s belongs to groups %s and %s", customerID, otherGroupID, groupID)
+			}
+			customerGroups[customerID] = groupID
+		}
+	}
+	logic.Groups = groups
+	logic.customerGroups = customerGroups
+	return nil
+}
+
+// groupLoads gives the accepted loads of all the customers of a group
+func (logic *FinanceLogic) groupLoads(groupID string) []Load {
+	loads := make([]Load, 0)
+	seen := make(map[string]interface{})
+	for _, customerID := range logic.Groups[groupID] {
+		if _, exist := seen[customerID]; exist {
+			continue
+		}
+		seen[customerID] = nil
+		loads = append(loads, logic.CustomersLoads[customerID]...)
+	}
+	return loads
+}
@@ -0,0 +1,143 @@
+package logic
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_SetGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups CustomerGroups
+		want   bool
+	}{
+		{
+			name:   "distinctGroups",
+			groups: CustomerGroups{"a": {"1", "2"}, "b": {"3"}},
+			want:   false,
+		},
+		{
+			name:   "customerInTwoGroups",
+			groups: CustomerGroups{"a": {"1", "2"}, "b": {"2"}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			if got := loadParser.SetGroups(tt.groups); (got != nil) != tt.want {
+				t.Errorf("SetGroups = %v, want error %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ReadGroups(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "groups.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"household-1": ["1", "2"]}`), 0644); err != nil {
+		t.Fatalf("WriteFile error %v", err)
+	}
+	if got, err := ReadGroups(filename); err != nil || !reflect.DeepEqual(got, CustomerGroups{"household-1": {"1", "2"}}) {
+		t.Errorf("ReadGroups = %v and %v", got, err)
+	}
+}
+
+func Test_ParseLoadsGroups(t *testing.T) {
+	type args struct {
+		policy      func(p *Policy)
+		loadStrings []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "groupDayAmount",
+			args: args{
+				policy: func(p *Policy) { p.GroupDayMaxAmount = 6000 },
+				loadStrings: []string{
+					`{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+					`{"id":"2","customer_id":"2","load_amount":"$3000.00","time":"2020-01-06T11:00:00Z"}`,
+					`{"id":"3","customer_id":"3","load_amount":"$3000.00","time":"2020-01-06T11:00:00Z"}`,
+				},
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true}`,
+				`{"id":"2","customer_id":"2","accepted":false,"breached_levels":["group"]}`,
+				`{"id":"3","customer_id":"3","accepted":true}`,
+			},
+		},
+		{
+			name: "groupDayCount",
+			args: args{
+				policy: func(p *Policy) { p.GroupDayMaxCount = 2 },
+				loadStrings: []string{
+					`{"id":"1","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T10:00:00Z"}`,
+					`{"id":"2","customer_id":"2","load_amount":"$10.00","time":"2020-01-06T11:00:00Z"}`,
+					`{"id":"3","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T12:00:00Z"}`,
+					`{"id":"4","customer_id":"1","load_amount":"$10.00","time":"2020-01-07T12:00:00Z"}`,
+				},
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true}`,
+				`{"id":"2","customer_id":"2","accepted":true}`,
+				`{"id":"3","customer_id":"1","accepted":false,"breached_levels":["group"]}`,
+				`{"id":"4","customer_id":"1","accepted":true}`,
+			},
+		},
+		{
+			name: "customerAndGroupWeek",
+			args: args{
+				policy: func(p *Policy) { p.GroupWeekMaxAmount = 8000 },
+				loadStrings: []string{
+					`{"id":"1","customer_id":"1","load_amount":"$5000.00","time":"2020-01-06T10:00:00Z"}`,
+					`{"id":"2","customer_id":"1","load_amount":"$5000.00","time":"2020-01-06T11:00:00Z"}`,
+					`{"id":"3","customer_id":"2","load_amount":"$2000.00","time":"2020-01-07T11:00:00Z"}`,
+				},
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true}`,
+				`{"id":"2","customer_id":"1","accepted":false,"breached_levels":["customer","group"]}`,
+				`{"id":"3","customer_id":"2","accepted":true}`,
+			},
+		},
+		{
+			name: "noGroupLimit",
+			args: args{
+				policy: func(p *Policy) {},
+				loadStrings: []string{
+					`{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+					`{"id":"2","customer_id":"2","load_amount":"$4000.00","time":"2020-01-06T11:00:00Z"}`,
+					`{"id":"3","customer_id":"2","load_amount":"$4000.00","time":"2020-01-06T12:00:00Z"}`,
+				},
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true}`,
+				`{"id":"2","customer_id":"2","accepted":true}`,
+				`{"id":"3","customer_id":"2","accepted":false,"breached_levels":["customer"]}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			tt.args.policy(&loadParser.Policies[0])
+			if err := loadParser.SetGroups(CustomerGroups{"household": {"1", "2"}}); err != nil {
+				t.Fatalf("SetGroups error %v", err)
+			}
+			stringChannel := make(chan string)
+			go func() {
+				for _, line := range tt.args.loadStrings {
+					stringChannel <- line
+				}
+				close(stringChannel)
+			}()
+			if gotLoads, gotErrors := loadParser.ParseLoads(stringChannel); !reflect.DeepEqual(gotLoads, tt.want) || len(gotErrors) != 0 {
+				t.Errorf("ParseLoads = %v,%v want %v", gotLoads, gotErrors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"math"
+	"time"
+)
+
+// WindowHeadroom what is used and what remains of one limit, in amount or in number of loads
+type WindowHeadroom struct {
+	Rule      string  `json:"rule"`
+	Window    string  `json:"window,omitempty"`
+	Level     string  `json:"level"`
+	Limit     float64 `json:"limit"`
+	Used      float64 `json:"used"`
+	Remaining float64 `json:"remaining"`
+}
+
+// Headroom what a customer can still load at a time, amount being the largest single load that would be accepted
+type Headroom struct {
+	CustomerID    string           `json:"customer_id"`
+	Time          time.Time        `json:"time"`
+	PolicyVersion string           `json:"policy_version"`
+	Amount        float64          `json:"amount"`
+	Windows       []WindowHeadroom `json:"windows"`
+	LockedUntil   *time.Time       `json:"locked_until,omitempty"`
+}
+
+// Headroom gives what remains of each limit of a customer for a load at a time, without changing the history
+func (logic *FinanceLogic) Headroom(customerID string, at time.Time) Headroom {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	probe := Load{CustomerID: customerID, Time: at}
+	policy := logic.Policies.At(at)
+	countLoads, amountLoads := logic.windowLoads([]string{customerID}, logic.CustomersLoads[customerID], policy, at)
+	evaluation := validateLoadWindows(probe, countLoads, amountLoads, policy)
+	if groupID, grouped := logic.customerGroups[customerID]; grouped {
+		groupCountLoads, groupAmountLoads := logic.windowLoads(logic.Groups[groupID], logic.groupLoads(groupID), policy, at)
+		evaluation.Rules = append(evaluation.Rules, validateGroupLoad(probe, groupCountLoads, groupAmountLoads, policy)...)
+	}
+	headroom := Headroom{
+		CustomerID:    customerID,
+		Time:          at,
+		PolicyVersion: policy.Version,
+		Amount:        math.Inf(1),
+		Windows:       make([]WindowHeadroom, 0, len(evaluation.Rules)),
+	}
+	for _, outcome := range evaluation.Rules {
+		if outcome.Level == LoadLevel {
+			continue
+		}
+		window := WindowHeadroom{
+			Rule:   outcome.Rule,
+			Window: outcome.Window,
+			Level:  outcome.Level,
+			Limit:  outcome.Limit,
+			Used:   outcome.Value,
+		}
+		if isCountRule(outcome.Rule) {
+			window.Used-- // the value of a count rule includes the load itself
+		}
+		window.Used = roundCents(window.Used)
+		window.Remaining = math.Max(0, roundCents(window.Limit-window.Used))
+		headroom.Windows = append(headroom.Windows, window)
+		if isCountRule(outcome.Rule) && window.Remaining == 0 {
+			headroom.Amount = 0
+		} else if !isCountRule(outcome.Rule) {
+			headroom.Amount = math.Min(headroom.Amount, window.Remaining)
+		}
+	}
+	if policy.LoadMaxAmount != 0 {
+		headroom.Amount = math.Min(headroom.Amount, policy.LoadMaxAmount)
+	}
+	if lock, locked := logic.lockedAt(customerID, at); locked {
+		headroom.LockedUntil = &lock.LockedUntil
+		headroom.Amount = 0
+	}
+	if policy.LoadMinAmount > headroom.Amount {
+		headroom.Amount = 0
+	}
+	return headroom
+}
+
+// roundCents rounds an amount to cents, sums of loads not being exact
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// isCountRule tells if a rule limits a number of loads rather than an amount
+func isCountRule(rule string) bool {
+	return rule == DayCountRule || rule == BurstCountRule
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Headroom(t *testing.T) {
+	lines := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$876.55","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"3","customer_id":"1","load_amount":"$5000.00","time":"2020-01-07T10:00:00Z"}`,
+		`{"id":"4","customer_id":"2","load_amount":"$10.00","time":"2020-01-06T10:00:00Z"}`,
+	}
+	type output struct {
+		amount  float64
+		windows []float64
+	}
+	tests := []struct {
+		name       string
+		customerID string
+		at         time.Time
+		want       output
+	}{
+		{
+			name:       "sameDay",
+			customerID: "1",
+			at:         time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC),
+			want:       output{amount: 123.45, windows: []float64{123.45, 1, 10123.45}},
+		},
+		{
+			name:       "dayLimitReached",
+			customerID: "1",
+			at:         time.Date(2020, time.January, 7, 12, 0, 0, 0, time.UTC),
+			want:       output{amount: 0, windows: []float64{0, 2, 10123.45}},
+		},
+		{
+			name:       "nextWeek",
+			customerID: "1",
+			at:         time.Date(2020, time.January, 13, 12, 0, 0, 0, time.UTC),
+			want:       output{amount: 5000, windows: []float64{5000, 3, 20000}},
+		},
+		{
+			name:       "unknownCustomer",
+			customerID: "3",
+			at:         time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC),
+			want:       output{amount: 5000, windows: []float64{5000, 3, 20000}},
+		},
+	}
+	loadParser := NewFinanceLogic()
+	for _, line := range lines {
+		if _, err := loadParser.ParseLoad(line); err != nil {
+			t.Fatalf("ParseLoad error %v", err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loadParser.Headroom(tt.customerID, tt.at)
+			remaining := make([]float64, 0)
+			for _, window := range got.Windows {
+				remaining = append(remaining, window.Remaining)
+			}
+			if got.Amount != tt.want.amount || !reflect.DeepEqual(remaining, tt.want.windows) {
+				t.Errorf("Headroom = %v and %v, want %v", got.Amount, remaining, tt.want)
+			}
+		})
+	}
+}
+
+func Test_HeadroomCountAndLock(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	for _, line := range []string{
+		`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"3","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T12:00:00Z"}`,
+	} {
+		if _, err := loadParser.ParseLoad(line); err != nil {
+			t.Fatalf("ParseLoad error %v", err)
+		}
+	}
+	at := time.Date(2020, time.January, 6, 13, 0, 0, 0, time.UTC)
+	if got := loadParser.Headroom("1", at); got.Amount != 0 {
+		t.Errorf("Headroom with no load left = %v, want 0", got.Amount)
+	}
+	until := time.Date(2020, time.January, 7, 0, 0, 0, 0, time.UTC)
+	loadParser.SetLock(CustomerLock{CustomerID: "2", LockedFrom: at.Add(-time.Hour), LockedUntil: until})
+	if got := loadParser.Headroom("2", at); got.Amount != 0 || got.LockedUntil == nil || !got.LockedUntil.Equal(until) {
+		t.Errorf("Headroom of locked customer = %v until %v, want 0 until %v", got.Amount, got.LockedUntil, until)
+	}
+}
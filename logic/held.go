@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// HeldLoad a load held for manual review
+type HeldLoad struct {
+	LoadID     string    `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	Amount     float64   `json:"amount"`
+	Time       time.Time `json:"time"`
+}
+
+// Held lists the loads waiting for a review ordered by time
+func (logic *FinanceLogic) Held() []HeldLoad {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	held := make([]HeldLoad, 0, len(logic.HeldLoads))
+	for _, load := range logic.HeldLoads {
+		held = append(held, toHeldLoad(load))
+	}
+	sortHeldLoads(held)
+	return held
+}
+
+// sortHeldLoads orders held loads by time
+func sortHeldLoads(held []HeldLoad) {
+	sort.Slice(held, func(i, j int) bool {
+		if held[i].Time.Equal(held[j].Time) {
+			return held[i].LoadID < held[j].LoadID
+		}
+		return held[i].Time.Before(held[j].Time)
+	})
+}
+
+// HoldLoad puts back a load waiting for a review, as when restoring a review queue
+func (logic *FinanceLogic) HoldLoad(held HeldLoad) {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	load := fromHeldLoad(held)
+	id := customerLoadID{LoadID: load.LoadID, CustomerID: load.CustomerID}
+	logic.TreatedLoadIds[id] = nil
+	logic.HeldLoads[id] = load
+}
+
+// ApproveHeld accepts a held load, counting it in the customer history at its original time
+func (logic *FinanceLogic) ApproveHeld(loadID string, customerID string) error {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	id := customerLoadID{LoadID: loadID, CustomerID: customerID}
+	load, held := logic.HeldLoads[id]
+	if !held {
+		return fmt.Errorf("load %s of customer %s is not held", loadID, customerID)
+	}
+	delete(logic.HeldLoads, id)
+	logic.addToHistory(load)
+	return nil
+}
+
+// DeclineHeld rejects a held load
+func (logic *FinanceLogic) DeclineHeld(loadID string, customerID string) error {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	id := customerLoadID{LoadID: loadID, CustomerID: customerID}
+	if _, held := logic.HeldLoads[id]; !held {
+		return fmt.Errorf("load %s of customer %s is not held", loadID, customerID)
+	}
+	delete(logic.HeldLoads, id)
+	return nil
+}
+
+// AddApproved counts a reviewed and approved load in the customer history, once even if added again
+func (logic *FinanceLogic) AddApproved(held HeldLoad) {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	load := fromHeldLoad(held)
+	delete(logic.HeldLoads, customerLoadID{LoadID: load.LoadID, CustomerID: load.CustomerID})
+	for _, storedLoad := range logic.CustomersLoads[load.CustomerID] {
+		if storedLoad.LoadID == load.LoadID {
+			return
+		}
+	}
+	logic.addToHistory(load)
+}
+
+// addToHistory marks a load as treated and counts it in the customer history
+func (logic *FinanceLogic) addToHistory(load Load) {
+	logic.TreatedLoadIds[customerLoadID{LoadID: load.LoadID, CustomerID: load.CustomerID}] = nil
+	logic.CustomersLoads[load.CustomerID] = append(logic.CustomersLoads[load.CustomerID], load)
+}
+
+// toHeldLoad converts a load to its exported form
+func toHeldLoad(load Load) HeldLoad {
+	return HeldLoad{
+		LoadID:     load.LoadID,
+		CustomerID: load.CustomerID,
+		Amount:     load.Amount.Value,
+		Time:       load.Time,
+	}
+}
+
+// fromHeldLoad converts an exported held load to a load
+func fromHeldLoad(held HeldLoad) Load {
+	return Load{
+		LoadID:     held.LoadID,
+		CustomerID: held.CustomerID,
+		Amount:     Amount{Value: held.Amount},
+		Time:       held.Time,
+	}
+}
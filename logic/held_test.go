@@ -0,0 +1,73 @@
+package logic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_reviewHeld(t *testing.T) {
+	heldLoad := HeldLoad{LoadID: "2", CustomerID: "1", Amount: 1000, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)}
+	type output struct {
+		hasError  bool
+		history   int
+		held      []HeldLoad
+		nextValid bool
+	}
+	tests := []struct {
+		name   string
+		review func(logic *FinanceLogic) error
+		want   output
+	}{
+		{
+			name:   "approve",
+			review: func(logic *FinanceLogic) error { return logic.ApproveHeld("2", "1") },
+			want:   output{hasError: false, history: 2, held: []HeldLoad{}, nextValid: false},
+		},
+		{
+			name:   "decline",
+			review: func(logic *FinanceLogic) error { return logic.DeclineHeld("2", "1") },
+			want:   output{hasError: false, history: 1, held: []HeldLoad{}, nextValid: true},
+		},
+		{
+			name:   "approveNotHeld",
+			review: func(logic *FinanceLogic) error { return logic.ApproveHeld("3", "1") },
+			want:   output{hasError: true, history: 1, held: []HeldLoad{heldLoad}, nextValid: true},
+		},
+		{
+			name:   "declineNotHeld",
+			review: func(logic *FinanceLogic) error { return logic.DeclineHeld("2", "2") },
+			want:   output{hasError: true, history: 1, held: []HeldLoad{heldLoad}, nextValid: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			loadParser.Policies[0].DayReviewMaxAmount = 6000
+			loadParser.AddApproved(HeldLoad{LoadID: "1", CustomerID: "1", Amount: 4500, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)})
+			if response, err := loadParser.ParseLoad(`{"id":"2","customer_id":"1","load_amount":"$1000.00","time":"2020-01-06T11:00:00Z"}`); err != nil || response != `{"id":"2","customer_id":"1","accepted":false,"pending":true}` {
+				t.Fatalf("ParseLoad = %v and %v, want pending", response, err)
+			}
+			err := tt.review(loadParser)
+			if (err != nil) != tt.want.hasError || len(loadParser.CustomersLoads["1"]) != tt.want.history || !reflect.DeepEqual(loadParser.Held(), tt.want.held) {
+				t.Errorf("review = %v, %v and %v, want %v", err, loadParser.CustomersLoads["1"], loadParser.Held(), tt.want)
+			}
+			response, _ := loadParser.ParseLoad(`{"id":"3","customer_id":"1","load_amount":"$500.00","time":"2020-01-06T12:00:00Z"}`)
+			if got := response == `{"id":"3","customer_id":"1","accepted":true}`; got != tt.want.nextValid {
+				t.Errorf("next load response %v, want accepted %v", response, tt.want.nextValid)
+			}
+		})
+	}
+}
+
+func Test_HoldLoad(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	heldLoad := HeldLoad{LoadID: "1", CustomerID: "1", Amount: 5500, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)}
+	loadParser.HoldLoad(heldLoad)
+	if got := loadParser.Held(); !reflect.DeepEqual(got, []HeldLoad{heldLoad}) {
+		t.Errorf("Held = %v, want %v", got, []HeldLoad{heldLoad})
+	}
+	if response, err := loadParser.ParseLoad(`{"id":"1","customer_id":"1","load_amount":"$5500.00","time":"2020-01-06T10:00:00Z"}`); response != "" || err != nil {
+		t.Errorf("ParseLoad = %v and %v, want held load treated", response, err)
+	}
+}
@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LockoutRule name of the rule rejecting the loads of a locked customer
+const LockoutRule = "lockout"
+
+// Lockout locks a customer for a cooldown once the number of rejected loads in a rolling window reaches a maximum
+type Lockout struct {
+	MaxRejections int      `json:"max_rejections"`
+	Window        Duration `json:"window"`
+	Cooldown      Duration `json:"cooldown"`
+}
+
+// Validate checks a lockout has a maximum, a window and a cooldown
+func (l Lockout) Validate() error {
+	if l.MaxRejections <= 0 {
+		return errors.New("lockout maximum rejections must be positive")
+	}
+	if l.Window.Duration <= 0 || l.Cooldown.Duration <= 0 {
+		return errors.New("lockout window and cooldown must be positive")
+	}
+	return nil
+}
+
+// CustomerLock period during which every load of a customer is rejected
+type CustomerLock struct {
+	CustomerID  string    `json:"customer_id"`
+	LockedFrom  time.Time `json:"locked_from"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Locks lists the locks of customers ordered by customer
+func (logic *FinanceLogic) Locks() []CustomerLock {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	return logic.locks()
+}
+
+// locks lists the locks of customers ordered by customer, the caller holding the mutex
+func (logic *FinanceLogic) locks() []CustomerLock {
+	locks := make([]CustomerLock, 0, len(logic.CustomerLocks))
+	for _, lock := range logic.CustomerLocks {
+		locks = append(locks, lock)
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].CustomerID < locks[j].CustomerID
+	})
+	return locks
+}
+
+// SetLock locks a customer, replacing its current lock, as when restoring saved locks
+func (logic *FinanceLogic) SetLock(lock CustomerLock) {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	logic.CustomerLocks[lock.CustomerID] = lock
+}
+
+// ReleaseLock unlocks a customer, its past rejections no longer counting towards a new lock
+func (logic *FinanceLogic) ReleaseLock(customerID string) error {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	if _, locked := logic.CustomerLocks[customerID]; !locked {
+		return fmt.Errorf("customer %s is not locked", customerID)
+	}
+	delete(logic.CustomerLocks, customerID)
+	delete(logic.RejectedLoads, customerID)
+	return nil
+}
+
+// lockedAt gives the lock of a customer in force at a time
+func (logic *FinanceLogic) lockedAt(customerID string, t time.Time) (CustomerLock, bool) {
+	lock, exist := logic.CustomerLocks[customerID]
+	if !exist || t.Before(lock.LockedFrom) || !t.Before(lock.LockedUntil) {
+		return lock, false
+	}
+	return lock, true
+}
+
+// applyLockout rejects the load of a locked customer, or records a rejected load and locks its customer when it
+// reaches the maximum rejections of the policy, the rejections before the window of the load being dropped
+func (logic *FinanceLogic) applyLockout(load Load, evaluation *loadEvaluation) {
+	lockout := evaluation.Policy.Lockout
+	if lock, locked := logic.lockedAt(load.CustomerID, load.Time); locked {
+		outcome := RuleOutcome{Rule: LockoutRule, Passed: false, Level: CustomerLevel}
+		if lockout != nil {
+			outcome.Limit = float64(lockout.MaxRejections)
+		}
+		evaluation.Rules = append(evaluation.Rules, outcome)
+		evaluation.decide()
+		evaluation.LockedUntil = &lock.LockedUntil
+		return
+	}
+	if lockout == nil || evaluation.Accepted || evaluation.Pending {
+		return
+	}
+	windowStart := load.Time.Add(-lockout.Window.Duration)
+	rejected := make([]Load, 0, len(logic.RejectedLoads[load.CustomerID])+1)
+	for _, rejectedLoad := range logic.RejectedLoads[load.CustomerID] {
+		if rejectedLoad.Time.After(windowStart) { // older rejections no longer count towards a lock
+			rejected = append(rejected, rejectedLoad)
+		}
+	}
+	rejected = append(rejected, load)
+	logic.RejectedLoads[load.CustomerID] = rejected
+	count := 0
+	for _, rejectedLoad := range rejected {
+		if !rejectedLoad.Time.After(load.Time) {
+			count++
+		}
+	}
+	if count >= lockout.MaxRejections {
+		lock := CustomerLock{
+			CustomerID:  load.CustomerID,
+			LockedFrom:  load.Time,
+			LockedUntil: load.Time.Add(lockout.Cooldown.Duration),
+		}
+		logic.CustomerLocks[load.CustomerID] = lock
+		evaluation.LockedUntil = &lock.LockedUntil
+	}
+}
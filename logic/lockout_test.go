@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Lockout(t *testing.T) {
+	lines := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$5500.00","time":"2020-01-06T10:01:00Z"}`,
+		`{"id":"3","customer_id":"1","load_amount":"$5001.00","time":"2020-01-06T10:02:00Z"}`,
+		`{"id":"4","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T10:03:00Z"}`,
+		`{"id":"5","customer_id":"2","load_amount":"$10.00","time":"2020-01-06T10:03:00Z"}`,
+		`{"id":"6","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T11:02:00Z"}`,
+		`{"id":"7","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T09:00:00Z"}`,
+	}
+	want := []string{
+		`{"id":"1","customer_id":"1","accepted":false}`,
+		`{"id":"2","customer_id":"1","accepted":false}`,
+		`{"id":"3","customer_id":"1","accepted":false,"locked_until":"2020-01-06T11:02:00Z"}`,
+		`{"id":"4","customer_id":"1","accepted":false,"rejection_reasons":["lockout"],"locked_until":"2020-01-06T11:02:00Z"}`,
+		`{"id":"5","customer_id":"2","accepted":true}`,
+		`{"id":"6","customer_id":"1","accepted":true}`,
+		`{"id":"7","customer_id":"1","accepted":true}`,
+	}
+	loadParser := NewFinanceLogic()
+	loadParser.Policies[0].Lockout = &Lockout{MaxRejections: 3, Window: Duration{10 * time.Minute}, Cooldown: Duration{time.Hour}}
+	for i, line := range lines {
+		if got, err := loadParser.ParseLoad(line); got != want[i] || err != nil {
+			t.Errorf("ParseLoad(%s) = %v and %v, want %v", line, got, err, want[i])
+		}
+	}
+	wantLocks := []CustomerLock{{
+		CustomerID:  "1",
+		LockedFrom:  time.Date(2020, time.January, 6, 10, 2, 0, 0, time.UTC),
+		LockedUntil: time.Date(2020, time.January, 6, 11, 2, 0, 0, time.UTC),
+	}}
+	if got := loadParser.Locks(); !reflect.DeepEqual(got, wantLocks) {
+		t.Errorf("Locks = %v, want %v", got, wantLocks)
+	}
+}
+
+func Test_LockoutWindow(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	loadParser.Policies[0].Lockout = &Lockout{MaxRejections: 2, Window: Duration{time.Minute}, Cooldown: Duration{time.Hour}}
+	lines := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:01:00Z"}`,
+		`{"id":"3","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T10:02:00Z"}`,
+	}
+	for _, line := range lines {
+		if _, err := loadParser.ParseLoad(line); err != nil {
+			t.Fatalf("ParseLoad error %v", err)
+		}
+	}
+	if got := loadParser.Locks(); len(got) != 0 {
+		t.Errorf("Locks = %v, want no lock for rejections outside of the window", got)
+	}
+	if got := loadParser.RejectedLoads["1"]; len(got) != 1 || got[0].LoadID != "2" {
+		t.Errorf("RejectedLoads = %v, want only the rejection in the window", got)
+	}
+}
+
+func Test_ReleaseLock(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	loadParser.SetLock(CustomerLock{
+		CustomerID:  "1",
+		LockedFrom:  time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC),
+		LockedUntil: time.Date(2020, time.January, 7, 0, 0, 0, 0, time.UTC),
+	})
+	load := `{"id":"1","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T10:00:00Z"}`
+	if got, _ := loadParser.ParseLoad(load); got != `{"id":"1","customer_id":"1","accepted":false,"rejection_reasons":["lockout"],"locked_until":"2020-01-07T00:00:00Z"}` {
+		t.Errorf("ParseLoad of locked customer = %v", got)
+	}
+	if err := loadParser.ReleaseLock("1"); err != nil {
+		t.Errorf("ReleaseLock error %v", err)
+	}
+	if err := loadParser.ReleaseLock("1"); err == nil {
+		t.Errorf("ReleaseLock of unlocked customer gives no error")
+	}
+	load = `{"id":"2","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T10:00:00Z"}`
+	if got, _ := loadParser.ParseLoad(load); got != `{"id":"2","customer_id":"1","accepted":true}` {
+		t.Errorf("ParseLoad of released customer = %v", got)
+	}
+}
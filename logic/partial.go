@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"math"
+)
+
+// acceptPartially caps a load rejected only by amount windows to the largest amount they all accept, giving the
+// capped load and its evaluation if accepted, the load and its evaluation otherwise
+func (logic *FinanceLogic) acceptPartially(load Load, customerLoads []Load, evaluation loadEvaluation) (Load, loadEvaluation) {
+	if evaluation.Accepted || evaluation.Pending {
+		return load, evaluation
+	}
+	capped := load
+	capped.Amount = Amount{Value: partialAmount(load, evaluation.Rules)}
+	if capped.Amount.Value <= 0 {
+		return load, evaluation
+	}
+	cappedEvaluation := logic.evaluate(capped, customerLoads, evaluation.Policy)
+	if !cappedEvaluation.Accepted {
+		return load, evaluation
+	}
+	cappedEvaluation.Partial = true
+	cappedEvaluation.RequestedAmount = load.Amount.Value
+	cappedEvaluation.AcceptedAmount = capped.Amount.Value
+	return capped, cappedEvaluation
+}
+
+// partialAmount gives the largest part of a load accepted by every failed rule rounded down to cents, 0 if a failed
+// rule does not limit an amount over a window
+func partialAmount(load Load, rules []RuleOutcome) float64 {
+	amount := load.Amount.Value
+	for _, outcome := range rules {
+		if outcome.Passed {
+			continue
+		}
+		if outcome.Level == LoadLevel || isCountRule(outcome.Rule) || outcome.Rule == LockoutRule {
+			return 0
+		}
+		amount = math.Min(amount, outcome.Limit-(outcome.Value-load.Amount.Value))
+	}
+	return math.Floor(amount*100+1e-6) / 100 // sums of loads not being exact, 122.9999999 is 123
+}
@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"testing"
+)
+
+func Test_PartialAcceptance(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []string
+		groups  CustomerGroups
+		line    string
+		want    string
+	}{
+		{
+			name:    "cappedToDay",
+			history: []string{`{"id":"1","customer_id":"1","load_amount":"$2000.00","time":"2020-01-06T10:00:00Z"}`},
+			line:    `{"id":"2","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T11:00:00Z"}`,
+			want:    `{"id":"2","customer_id":"1","accepted":true,"requested_amount":"$4000.00","accepted_amount":"$3000.00"}`,
+		},
+		{
+			name:    "cappedToCents",
+			history: []string{`{"id":"1","customer_id":"1","load_amount":"$4876.55","time":"2020-01-06T10:00:00Z"}`},
+			line:    `{"id":"2","customer_id":"1","load_amount":"$500.00","time":"2020-01-06T11:00:00Z"}`,
+			want:    `{"id":"2","customer_id":"1","accepted":true,"requested_amount":"$500.00","accepted_amount":"$123.45"}`,
+		},
+		{
+			name: "cappedToWeek",
+			history: []string{
+				`{"id":"1","customer_id":"1","load_amount":"$5000.00","time":"2020-01-06T10:00:00Z"}`,
+				`{"id":"2","customer_id":"1","load_amount":"$5000.00","time":"2020-01-07T10:00:00Z"}`,
+				`{"id":"3","customer_id":"1","load_amount":"$5000.00","time":"2020-01-08T10:00:00Z"}`,
+				`{"id":"4","customer_id":"1","load_amount":"$4000.00","time":"2020-01-09T10:00:00Z"}`,
+			},
+			line: `{"id":"5","customer_id":"1","load_amount":"$3000.00","time":"2020-01-10T10:00:00Z"}`,
+			want: `{"id":"5","customer_id":"1","accepted":true,"requested_amount":"$3000.00","accepted_amount":"$1000.00"}`,
+		},
+		{
+			name:    "cappedToGroup",
+			history: []string{`{"id":"1","customer_id":"2","load_amount":"$5000.00","time":"2020-01-06T10:00:00Z"}`},
+			groups:  CustomerGroups{"household": {"1", "2"}},
+			line:    `{"id":"2","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T11:00:00Z"}`,
+			want:    `{"id":"2","customer_id":"1","accepted":true,"requested_amount":"$4000.00","accepted_amount":"$1000.00"}`,
+		},
+		{
+			name: "countNotCapped",
+			history: []string{
+				`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T10:00:00Z"}`,
+				`{"id":"2","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T11:00:00Z"}`,
+				`{"id":"3","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T12:00:00Z"}`,
+			},
+			line: `{"id":"4","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T13:00:00Z"}`,
+			want: `{"id":"4","customer_id":"1","accepted":false}`,
+		},
+		{
+			name:    "nothingLeft",
+			history: []string{`{"id":"1","customer_id":"1","load_amount":"$5000.00","time":"2020-01-06T10:00:00Z"}`},
+			line:    `{"id":"2","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T11:00:00Z"}`,
+			want:    `{"id":"2","customer_id":"1","accepted":false}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			loadParser.Policies[0].PartialAcceptance = true
+			loadParser.Policies[0].GroupDayMaxAmount = 6000
+			if tt.groups != nil {
+				if err := loadParser.SetGroups(tt.groups); err != nil {
+					t.Fatalf("SetGroups error %v", err)
+				}
+			}
+			for _, line := range tt.history {
+				if _, err := loadParser.ParseLoad(line); err != nil {
+					t.Fatalf("ParseLoad error %v", err)
+				}
+			}
+			if got, err := loadParser.ParseLoad(tt.line); got != tt.want || err != nil {
+				t.Errorf("ParseLoad = %v and %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PartialAcceptanceHistory(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	loadParser.Policies[0].PartialAcceptance = true
+	for _, line := range []string{
+		`{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T11:00:00Z"}`,
+	} {
+		if _, err := loadParser.ParseLoad(line); err != nil {
+			t.Fatalf("ParseLoad error %v", err)
+		}
+	}
+	loads := loadParser.CustomersLoads["1"]
+	if len(loads) != 2 || loads[1].Amount.Value != 1000 {
+		t.Errorf("CustomersLoads = %v, want the accepted part only", loads)
+	}
+}
@@ -0,0 +1,228 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+const defaultPolicyVersion = "1"
+
+// Policy limits applied to each customer
+// loads going over an amount limit but not over its review limit are held for manual review, 0 disables the review
+// group limits apply to the loads of all the customers of a group, 0 disables the limit
+// load limits apply to the amount of each load alone, 0 disables the limit
+// burst limits apply to the loads of a customer over short rolling durations
+// the lockout, when set, locks a customer after repeated rejected loads
+// counting tells which loads besides the accepted ones count in the windows
+// zero, negative and sub-cent loads are only rejected when the policy asks for it
+// with partial acceptance, a load going over amount limits is accepted for the amount remaining under them
+// reserved loads expire after the reservation time to live, a week if not set
+// the policy applies to loads from its effective time until the effective time of the next version
+type Policy struct {
+	Version              string       `json:"version"`
+	EffectiveFrom        time.Time    `json:"effective_from"`
+	DayMaxAmount         float64      `json:"day_max_amount"`
+	DayMaxCount          int          `json:"day_max_count"`
+	WeekMaxAmount        float64      `json:"week_max_amount"`
+	DayReviewMaxAmount   float64      `json:"day_review_max_amount,omitempty"`
+	WeekReviewMaxAmount  float64      `json:"week_review_max_amount,omitempty"`
+	GroupDayMaxAmount    float64      `json:"group_day_max_amount,omitempty"`
+	GroupDayMaxCount     int          `json:"group_day_max_count,omitempty"`
+	GroupWeekMaxAmount   float64      `json:"group_week_max_amount,omitempty"`
+	LoadMinAmount        float64      `json:"load_min_amount,omitempty"`
+	LoadMaxAmount        float64      `json:"load_max_amount,omitempty"`
+	RejectZeroAmount     bool         `json:"reject_zero_amount,omitempty"`
+	RejectNegativeAmount bool         `json:"reject_negative_amount,omitempty"`
+	RejectSubCentAmount  bool         `json:"reject_sub_cent_amount,omitempty"`
+	BurstLimits          []BurstLimit `json:"burst_limits,omitempty"`
+	Lockout              *Lockout     `json:"lockout,omitempty"`
+	Counting             Counting     `json:"counting"`
+	PartialAcceptance    bool         `json:"partial_acceptance,omitempty"`
+	ReservationTTL       Duration     `json:"reservation_ttl"`
+}
+
+// DefaultPolicy gives the policy with the historical limits
+func DefaultPolicy() Policy {
+	return Policy{
+		Version:       defaultPolicyVersion,
+		DayMaxAmount:  dayMaxAmount,
+		DayMaxCount:   dayMaxCount,
+		WeekMaxAmount: weekMaxAmount,
+	}
+}
+
+// ReadPolicy reads a json policy file, missing fields keeping their default value
+func ReadPolicy(filename string) (Policy, error) {
+	policy := DefaultPolicy()
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(content, &policy); err != nil {
+		return policy, err
+	}
+	return policy, policy.Validate()
+}
+
+// ReadPolicies reads a json policy file holding either one policy or a list of effective-dated versions
+// missing fields of each version keeping their default value
+func ReadPolicies(filename string) (PolicyHistory, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		policy := DefaultPolicy()
+		if err := json.Unmarshal(content, &policy); err != nil {
+			return nil, err
+		}
+		return PolicyHistory{policy}, policy.Validate()
+	}
+	versions := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(content, &versions); err != nil {
+		return nil, err
+	}
+	policies := make(PolicyHistory, 0, len(versions))
+	for _, version := range versions {
+		policy := DefaultPolicy()
+		if err := json.Unmarshal(version, &policy); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	policies.sort()
+	return policies, policies.Validate()
+}
+
+// Validate checks the limits of a policy are consistent
+func (p Policy) Validate() error {
+	if p.Version == "" {
+		return errors.New("policy version is needed")
+	}
+	if p.DayMaxAmount < 0 || p.DayMaxCount < 0 || p.WeekMaxAmount < 0 {
+		return errors.New("policy limits must be positive")
+	}
+	if p.GroupDayMaxAmount < 0 || p.GroupDayMaxCount < 0 || p.GroupWeekMaxAmount < 0 {
+		return errors.New("policy group limits must be positive")
+	}
+	if p.LoadMinAmount < 0 || p.LoadMaxAmount < 0 {
+		return errors.New("policy load limits must be positive")
+	}
+	if p.LoadMaxAmount != 0 && p.LoadMaxAmount < p.LoadMinAmount {
+		return errors.New("load maximum amount must be greater than the load minimum amount")
+	}
+	for _, burst := range p.BurstLimits {
+		if err := burst.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Lockout != nil {
+		if err := p.Lockout.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.DayReviewMaxAmount != 0 && p.DayReviewMaxAmount < p.DayMaxAmount {
+		return errors.New("day review limit must be greater than the day limit")
+	}
+	if p.WeekReviewMaxAmount != 0 && p.WeekReviewMaxAmount < p.WeekMaxAmount {
+		return errors.New("week review limit must be greater than the week limit")
+	}
+	return nil
+}
+
+// PolicyHistory versions of the policy sorted by effective time
+type PolicyHistory []Policy
+
+// At gives the version effective at a time, the oldest version for a time before all of them
+func (h PolicyHistory) At(t time.Time) Policy {
+	policy := h[0]
+	for _, version := range h[1:] {
+		if version.EffectiveFrom.After(t) {
+			break
+		}
+		policy = version
+	}
+	return policy
+}
+
+// Validate checks each version is consistent and no two versions start at the same time
+func (h PolicyHistory) Validate() error {
+	if len(h) == 0 {
+		return errors.New("at least one policy version is needed")
+	}
+	for i, policy := range h {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policy version %s: %v", policy.Version, err)
+		}
+		if i > 0 && policy.EffectiveFrom.Equal(h[i-1].EffectiveFrom) {
+			return fmt.Errorf("policy versions %s and %s have the same effective time", h[i-1].Version, policy.Version)
+		}
+	}
+	return nil
+}
+
+// sort orders the versions by effective time, keeping the given order of versions with the same time
+func (h PolicyHistory) sort() {
+	sort.SliceStable(h, func(i, j int) bool {
+		return h[i].EffectiveFrom.Before(h[j].EffectiveFrom)
+	})
+}
+
+// CurrentPolicies gives the policy versions applied to the next loads
+func (logic *FinanceLogic) CurrentPolicies() PolicyHistory {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	return logic.Policies
+}
+
+// SetPolicy applies a single policy to the next loads whatever their time
+func (logic *FinanceLogic) SetPolicy(policy Policy) error {
+	return logic.SetPolicies(PolicyHistory{policy})
+}
+
+// SetPolicies validates policy versions and applies them to the next loads, the replaced versions being kept for
+// a rollback
+func (logic *FinanceLogic) SetPolicies(policies PolicyHistory) error {
+	policies = append(PolicyHistory{}, policies...)
+	policies.sort()
+	if err := policies.Validate(); err != nil {
+		return err
+	}
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	logic.previousPolicies = append(logic.previousPolicies, logic.Policies)
+	logic.Policies = policies
+	return nil
+}
+
+// RollbackPolicy applies again the policy versions replaced by the last SetPolicies
+func (logic *FinanceLogic) RollbackPolicy() (PolicyHistory, error) {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	if len(logic.previousPolicies) == 0 {
+		return logic.Policies, errors.New("no previous policy to roll back to")
+	}
+	logic.Policies = logic.previousPolicies[len(logic.previousPolicies)-1]
+	logic.previousPolicies = logic.previousPolicies[:len(logic.previousPolicies)-1]
+	return logic.Policies, nil
+}
+
+// reviewable tells if a failed rule can be held for review instead of being rejected
+// only customer limits can be reviewed
+func (p Policy) reviewable(outcome RuleOutcome) bool {
+	if outcome.Level != CustomerLevel {
+		return false
+	}
+	switch outcome.Rule {
+	case DayAmountRule:
+		return p.DayReviewMaxAmount != 0 && outcome.Value <= p.DayReviewMaxAmount
+	case WeekAmountRule:
+		return p.WeekReviewMaxAmount != 0 && outcome.Value <= p.WeekReviewMaxAmount
+	}
+	return false
+}
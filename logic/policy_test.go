@@ -0,0 +1,259 @@
+package logic
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_ReadPolicy(t *testing.T) {
+	type output struct {
+		policy   Policy
+		hasError bool
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    output
+	}{
+		{
+			name:    "partialPolicy",
+			content: `{"version": "2", "day_review_max_amount": 6000}`,
+			want: output{
+				policy: Policy{
+					Version:            "2",
+					DayMaxAmount:       dayMaxAmount,
+					DayMaxCount:        dayMaxCount,
+					WeekMaxAmount:      weekMaxAmount,
+					DayReviewMaxAmount: 6000,
+				},
+				hasError: false,
+			},
+		},
+		{
+			name:    "reviewBelowLimit",
+			content: `{"version": "2", "week_review_max_amount": 1000}`,
+			want: output{
+				policy: Policy{
+					Version:             "2",
+					DayMaxAmount:        dayMaxAmount,
+					DayMaxCount:         dayMaxCount,
+					WeekMaxAmount:       weekMaxAmount,
+					WeekReviewMaxAmount: 1000,
+				},
+				hasError: true,
+			},
+		},
+		{
+			name:    "noVersion",
+			content: `{"version": ""}`,
+			want: output{
+				policy: Policy{
+					DayMaxAmount:  dayMaxAmount,
+					DayMaxCount:   dayMaxCount,
+					WeekMaxAmount: weekMaxAmount,
+				},
+				hasError: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "policy.json")
+			if err := ioutil.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile error %v", err)
+			}
+			if got, err := ReadPolicy(filename); !reflect.DeepEqual(got, tt.want.policy) || (err != nil) != tt.want.hasError {
+				t.Errorf("ReadPolicy = %v and %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateLoadReview(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.DayReviewMaxAmount = 6000
+	policy.WeekReviewMaxAmount = 22000
+	history := []Load{
+		{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 5000}, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)},
+		{LoadID: "2", CustomerID: "1", Amount: Amount{Value: 5000}, Time: time.Date(2020, time.January, 7, 10, 0, 0, 0, time.UTC)},
+		{LoadID: "3", CustomerID: "1", Amount: Amount{Value: 5000}, Time: time.Date(2020, time.January, 8, 10, 0, 0, 0, time.UTC)},
+		{LoadID: "4", CustomerID: "1", Amount: Amount{Value: 3000}, Time: time.Date(2020, time.January, 9, 10, 0, 0, 0, time.UTC)},
+	}
+	type output struct {
+		accepted bool
+		pending  bool
+	}
+	tests := []struct {
+		name string
+		load Load
+		want output
+	}{
+		{
+			name: "underLimit",
+			load: Load{LoadID: "5", CustomerID: "1", Amount: Amount{Value: 2000}, Time: time.Date(2020, time.January, 9, 12, 0, 0, 0, time.UTC)},
+			want: output{accepted: true, pending: false},
+		},
+		{
+			name: "dayReview",
+			load: Load{LoadID: "5", CustomerID: "1", Amount: Amount{Value: 1000}, Time: time.Date(2020, time.January, 8, 12, 0, 0, 0, time.UTC)},
+			want: output{accepted: false, pending: true},
+		},
+		{
+			name: "overDayReview",
+			load: Load{LoadID: "5", CustomerID: "1", Amount: Amount{Value: 1500}, Time: time.Date(2020, time.January, 8, 12, 0, 0, 0, time.UTC)},
+			want: output{accepted: false, pending: false},
+		},
+		{
+			name: "weekReview",
+			load: Load{LoadID: "5", CustomerID: "1", Amount: Amount{Value: 4000}, Time: time.Date(2020, time.January, 10, 10, 0, 0, 0, time.UTC)},
+			want: output{accepted: false, pending: true},
+		},
+		{
+			name: "dayReviewAndWeekRejected",
+			load: Load{LoadID: "5", CustomerID: "1", Amount: Amount{Value: 5500}, Time: time.Date(2020, time.January, 10, 10, 0, 0, 0, time.UTC)},
+			want: output{accepted: false, pending: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateLoad(tt.load, history, PolicyHistory{policy})
+			if got.Accepted != tt.want.accepted || got.Pending != tt.want.pending {
+				t.Errorf("validateLoad = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_SetPolicyRollback(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	if _, err := loadParser.RollbackPolicy(); err == nil {
+		t.Errorf("RollbackPolicy without previous policy gives no error")
+	}
+	stricter := DefaultPolicy()
+	stricter.Version = "2"
+	stricter.DayMaxAmount = 1000
+	if err := loadParser.SetPolicy(stricter); err != nil {
+		t.Fatalf("SetPolicy error %v", err)
+	}
+	if err := loadParser.SetPolicy(Policy{Version: "3", DayMaxAmount: -1}); err == nil {
+		t.Errorf("SetPolicy with invalid policy gives no error")
+	}
+	decision, _ := loadParser.Validate(context.Background(), Load{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 2000}, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)})
+	if decision.Accepted || decision.PolicyVersion != "2" {
+		t.Errorf("Validate = %v, want rejected with policy 2", decision)
+	}
+	if policies, err := loadParser.RollbackPolicy(); err != nil || !reflect.DeepEqual(policies, PolicyHistory{DefaultPolicy()}) {
+		t.Errorf("RollbackPolicy = %v and %v, want default policy", policies, err)
+	}
+	decision, _ = loadParser.Validate(context.Background(), Load{LoadID: "2", CustomerID: "1", Amount: Amount{Value: 2000}, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)})
+	if !decision.Accepted || decision.PolicyVersion != "1" {
+		t.Errorf("Validate = %v, want accepted with policy 1", decision)
+	}
+}
+
+func Test_ReadPolicies(t *testing.T) {
+	type output struct {
+		versions []string
+		hasError bool
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    output
+	}{
+		{
+			name:    "singlePolicy",
+			content: `{"version": "2", "day_max_amount": 3000}`,
+			want:    output{versions: []string{"2"}},
+		},
+		{
+			name: "sortedByEffectiveTime",
+			content: `[{"version": "3", "effective_from": "2020-02-01T00:00:00Z", "day_max_amount": 4000},
+				{"version": "2", "effective_from": "2020-01-01T00:00:00Z", "day_max_amount": 3000}]`,
+			want: output{versions: []string{"2", "3"}},
+		},
+		{
+			name:    "sameEffectiveTime",
+			content: `[{"version": "2", "effective_from": "2020-01-01T00:00:00Z"}, {"version": "3", "effective_from": "2020-01-01T00:00:00Z"}]`,
+			want:    output{hasError: true},
+		},
+		{
+			name:    "invalidVersion",
+			content: `[{"version": "2"}, {"version": "3", "effective_from": "2020-01-01T00:00:00Z", "day_max_count": -1}]`,
+			want:    output{hasError: true},
+		},
+		{
+			name:    "noVersion",
+			content: `[]`,
+			want:    output{hasError: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "policies.json")
+			if err := ioutil.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile error %v", err)
+			}
+			policies, err := ReadPolicies(filename)
+			if (err != nil) != tt.want.hasError {
+				t.Fatalf("ReadPolicies error %v, want error %v", err, tt.want.hasError)
+			}
+			if err != nil {
+				return
+			}
+			versions := make([]string, 0)
+			for _, policy := range policies {
+				versions = append(versions, policy.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.want.versions) {
+				t.Errorf("ReadPolicies versions = %v, want %v", versions, tt.want.versions)
+			}
+		})
+	}
+}
+
+func Test_validateLoadEffectiveDated(t *testing.T) {
+	original := DefaultPolicy()
+	stricter := DefaultPolicy()
+	stricter.Version = "2"
+	stricter.EffectiveFrom = time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+	stricter.DayMaxAmount = 1000
+	policies := PolicyHistory{original, stricter}
+	type output struct {
+		accepted bool
+		version  string
+	}
+	tests := []struct {
+		name string
+		time time.Time
+		want output
+	}{
+		{
+			name: "beforeChange",
+			time: time.Date(2020, time.January, 31, 23, 59, 59, 0, time.UTC),
+			want: output{accepted: true, version: "1"},
+		},
+		{
+			name: "atChange",
+			time: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+			want: output{accepted: false, version: "2"},
+		},
+		{
+			name: "afterChange",
+			time: time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC),
+			want: output{accepted: false, version: "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateLoad(Load{LoadID: "1", CustomerID: "1", Amount: Amount{Value: 2000}, Time: tt.time}, []Load{}, policies)
+			if got.Accepted != tt.want.accepted || got.Policy.Version != tt.want.version {
+				t.Errorf("validateLoad = %v with policy %s, want %v", got.Accepted, got.Policy.Version, tt.want)
+			}
+		})
+	}
+}
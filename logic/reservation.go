@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultReservationTTL = 7 * 24 * time.Hour
+
+	// ReserveEvent line reserving a load, validated as a load but only counting until captured or expired
+	ReserveEvent = "reserve"
+	// CaptureEvent line capturing a reserved load so it counts in the history
+	CaptureEvent = "capture"
+	// ReleaseEvent line releasing a reserved load
+	ReleaseEvent = "release"
+
+	// ReservedStatus the load was accepted and reserved
+	ReservedStatus = "reserved"
+	// CapturedStatus the reserved load was captured
+	CapturedStatus = "captured"
+	// ReleasedStatus the reserved load was released
+	ReleasedStatus = "released"
+)
+
+// Reservation an accepted load counting in the windows of the loads before its expiry, until captured or released
+type Reservation struct {
+	Load      Load      `json:"load"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Reserve validates a load and, when accepted, reserves it until captured, released or expired
+// a decision can come with an error when it was taken but not audited
+func (logic *FinanceLogic) Reserve(ctx context.Context, load Load) (Decision, error) {
+	if err := ctx.Err(); err != nil {
+		return Decision{}, err
+	}
+	return logic.validate(load, nil, true)
+}
+
+// Capture adds a reserved load to the history at its original time, if not expired at the time of the capture, which
+// is needed
+func (logic *FinanceLogic) Capture(loadID string, customerID string, at time.Time) error {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	if at.IsZero() {
+		return fmt.Errorf("capture of load %s of customer %s has no time", loadID, customerID)
+	}
+	i, err := logic.findReservation(loadID, customerID)
+	if err != nil {
+		return err
+	}
+	if reservation := logic.Reservations[customerID][i]; !at.Before(reservation.ExpiresAt) {
+		return fmt.Errorf("reservation of load %s of customer %s expired at %s", loadID, customerID, reservation.ExpiresAt.Format(time.RFC3339))
+	}
+	logic.addToHistory(logic.removeReservationAt(customerID, i).Load)
+	return nil
+}
+
+// Release removes a reserved load, which no longer counts in the windows
+func (logic *FinanceLogic) Release(loadID string, customerID string) error {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	i, err := logic.findReservation(loadID, customerID)
+	if err != nil {
+		return err
+	}
+	logic.removeReservationAt(customerID, i)
+	return nil
+}
+
+// reserve keeps an accepted load as reserved until the reservation time to live of the policy
+func (logic *FinanceLogic) reserve(load Load, policy Policy) {
+	ttl := policy.ReservationTTL.Duration
+	if ttl == 0 {
+		ttl = defaultReservationTTL
+	}
+	logic.Reservations[load.CustomerID] = append(logic.Reservations[load.CustomerID], Reservation{
+		Load:      load,
+		ExpiresAt: load.Time.Add(ttl),
+	})
+}
+
+// findReservation gives the index of the reservation of a load among those of its customer
+func (logic *FinanceLogic) findReservation(loadID string, customerID string) (int, error) {
+	for i, reservation := range logic.Reservations[customerID] {
+		if reservation.Load.LoadID == loadID {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("load %s of customer %s is not reserved", loadID, customerID)
+}
+
+// removeReservationAt removes and gives a reservation of a customer by index
+func (logic *FinanceLogic) removeReservationAt(customerID string, i int) Reservation {
+	reservations := logic.Reservations[customerID]
+	reservation := reservations[i]
+	logic.Reservations[customerID] = append(reservations[:i:i], reservations[i+1:]...)
+	if len(logic.Reservations[customerID]) == 0 {
+		delete(logic.Reservations, customerID)
+	}
+	return reservation
+}
+
+// expireReservations drops the reservations of a customer expired at a time, which no longer count nor can be
+// captured, so they do not stay in the state
+func (logic *FinanceLogic) expireReservations(customerID string, at time.Time) {
+	reservations, exist := logic.Reservations[customerID]
+	if !exist {
+		return
+	}
+	active := reservations[:0]
+	for _, reservation := range reservations {
+		if at.Before(reservation.ExpiresAt) {
+			active = append(active, reservation)
+		}
+	}
+	if len(active) == 0 {
+		delete(logic.Reservations, customerID)
+		return
+	}
+	logic.Reservations[customerID] = active
+}
+
+// activeReservations gives the reserved loads of customers not expired at a time
+func (logic *FinanceLogic) activeReservations(customerIDs []string, at time.Time) []Load {
+	var loads []Load
+	for _, customerID := range customerIDs {
+		for _, reservation := range logic.Reservations[customerID] {
+			if at.Before(reservation.ExpiresAt) {
+				loads = append(loads, reservation.Load)
+			}
+		}
+	}
+	return loads
+}
+
+// parseReservationEvent applies a capture or release line, giving the response of the reserved load
+func (logic *FinanceLogic) parseReservationEvent(eventType string, load Load) (Decision, error) {
+	decision := Decision{LoadID: load.LoadID, CustomerID: load.CustomerID}
+	switch eventType {
+	case CaptureEvent:
+		if err := logic.Capture(load.LoadID, load.CustomerID, load.Time); err != nil {
+			return decision, err
+		}
+		decision.Accepted = true
+		decision.Reservation = CapturedStatus
+	case ReleaseEvent:
+		if err := logic.Release(load.LoadID, load.CustomerID); err != nil {
+			return decision, err
+		}
+		decision.Reservation = ReleasedStatus
+	default:
+		return decision, fmt.Errorf("unknown event type %s", eventType)
+	}
+	return decision, nil
+}
@@ -0,0 +1,124 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_ParseLoadReservations(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name: "reservationCounts",
+			lines: []string{
+				`{"type":"reserve","id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+				`{"id":"2","customer_id":"1","load_amount":"$2000.00","time":"2020-01-06T11:00:00Z"}`,
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true,"reservation":"reserved"}`,
+				`{"id":"2","customer_id":"1","accepted":false}`,
+			},
+		},
+		{
+			name: "capturedCounts",
+			lines: []string{
+				`{"type":"reserve","id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+				`{"type":"capture","id":"1","customer_id":"1","time":"2020-01-06T10:30:00Z"}`,
+				`{"id":"2","customer_id":"1","load_amount":"$2000.00","time":"2020-01-06T11:00:00Z"}`,
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true,"reservation":"reserved"}`,
+				`{"id":"1","customer_id":"1","accepted":true,"reservation":"captured"}`,
+				`{"id":"2","customer_id":"1","accepted":false}`,
+			},
+		},
+		{
+			name: "releasedNoLongerCounts",
+			lines: []string{
+				`{"type":"reserve","id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+				`{"type":"release","id":"1","customer_id":"1","time":"2020-01-06T10:30:00Z"}`,
+				`{"id":"2","customer_id":"1","load_amount":"$2000.00","time":"2020-01-06T11:00:00Z"}`,
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true,"reservation":"reserved"}`,
+				`{"id":"1","customer_id":"1","accepted":false,"reservation":"released"}`,
+				`{"id":"2","customer_id":"1","accepted":true}`,
+			},
+		},
+		{
+			name: "expiredNoLongerCounts",
+			lines: []string{
+				`{"type":"reserve","id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+				`{"id":"2","customer_id":"1","load_amount":"$2000.00","time":"2020-01-06T12:00:00Z"}`,
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":true,"reservation":"reserved"}`,
+				`{"id":"2","customer_id":"1","accepted":true}`,
+			},
+		},
+		{
+			name: "rejectedNotReserved",
+			lines: []string{
+				`{"type":"reserve","id":"1","customer_id":"1","load_amount":"$6000.00","time":"2020-01-06T10:00:00Z"}`,
+			},
+			want: []string{
+				`{"id":"1","customer_id":"1","accepted":false}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadParser := NewFinanceLogic()
+			loadParser.Policies[0].ReservationTTL = Duration{2 * time.Hour}
+			if tt.name == "expiredNoLongerCounts" {
+				loadParser.Policies[0].ReservationTTL = Duration{time.Hour}
+			}
+			for i, line := range tt.lines {
+				if got, err := loadParser.ParseLoad(line); got != tt.want[i] || err != nil {
+					t.Errorf("ParseLoad(%s) = %v and %v, want %v", line, got, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_Capture(t *testing.T) {
+	loadParser := NewFinanceLogic()
+	loadParser.Policies[0].ReservationTTL = Duration{time.Hour}
+	reserved := time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)
+	for _, id := range []string{"1", "2"} {
+		decision, err := loadParser.Reserve(context.Background(), Load{LoadID: id, CustomerID: "1", Amount: Amount{Value: 100}, Time: reserved})
+		if err != nil || decision.Reservation != ReservedStatus {
+			t.Fatalf("Reserve = %v and %v, want reserved", decision, err)
+		}
+	}
+	if err := loadParser.Capture("1", "1", reserved.Add(30*time.Minute)); err != nil {
+		t.Errorf("Capture before expiry error %v", err)
+	}
+	if err := loadParser.Capture("2", "1", time.Time{}); err == nil {
+		t.Errorf("Capture without time gives no error")
+	}
+	if err := loadParser.Capture("2", "1", reserved.Add(time.Hour)); err == nil {
+		t.Errorf("Capture at expiry gives no error")
+	}
+	if len(loadParser.Reservations["1"]) != 1 {
+		t.Errorf("Reservations = %v, want the expired reservation kept until a later load", loadParser.Reservations)
+	}
+	if _, err := loadParser.ParseLoad(`{"id":"4","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T11:00:00Z"}`); err != nil {
+		t.Fatalf("ParseLoad error %v", err)
+	}
+	if err := loadParser.Capture("1", "1", reserved.Add(30*time.Minute)); err == nil {
+		t.Errorf("Capture of captured load gives no error")
+	}
+	if err := loadParser.Release("3", "1"); err == nil {
+		t.Errorf("Release of unknown load gives no error")
+	}
+	loads := loadParser.CustomersLoads["1"]
+	if len(loads) != 2 || loads[0].LoadID != "1" || len(loadParser.Reservations) != 0 {
+		t.Errorf("CustomersLoads = %v and Reservations = %v, want the captured and the later load only", loads, loadParser.Reservations)
+	}
+}
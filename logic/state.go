@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"sort"
+)
+
+// State the history of a FinanceLogic, serializable to json
+type State struct {
+	CustomersLoads map[string][]Load        `json:"customers_loads"`
+	TreatedLoadIds []customerLoadID         `json:"treated_load_ids"`
+	HeldLoads      []HeldLoad               `json:"held_loads"`
+	RejectedLoads  map[string][]Load        `json:"rejected_loads"`
+	Locks          []CustomerLock           `json:"locks"`
+	CountedLoads   map[string][]CountedLoad `json:"counted_loads"`
+	Reservations   map[string][]Reservation `json:"reservations"`
+}
+
+// Snapshot gives a copy of the current history
+func (logic *FinanceLogic) Snapshot() State {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	state := State{
+		CustomersLoads: make(map[string][]Load, len(logic.CustomersLoads)),
+		TreatedLoadIds: make([]customerLoadID, 0, len(logic.TreatedLoadIds)),
+		HeldLoads:      make([]HeldLoad, 0, len(logic.HeldLoads)),
+		RejectedLoads:  make(map[string][]Load, len(logic.RejectedLoads)),
+		Locks:          logic.locks(),
+		CountedLoads:   make(map[string][]CountedLoad, len(logic.CountedLoads)),
+		Reservations:   make(map[string][]Reservation, len(logic.Reservations)),
+	}
+	for customerID, loads := range logic.CustomersLoads {
+		state.CustomersLoads[customerID] = append([]Load(nil), loads...)
+	}
+	for customerID, loads := range logic.RejectedLoads {
+		state.RejectedLoads[customerID] = append([]Load(nil), loads...)
+	}
+	for customerID, loads := range logic.CountedLoads {
+		state.CountedLoads[customerID] = append([]CountedLoad(nil), loads...)
+	}
+	for customerID, reservations := range logic.Reservations {
+		state.Reservations[customerID] = append([]Reservation(nil), reservations...)
+	}
+	for id := range logic.TreatedLoadIds {
+		state.TreatedLoadIds = append(state.TreatedLoadIds, id)
+	}
+	sort.Slice(state.TreatedLoadIds, func(i, j int) bool {
+		if state.TreatedLoadIds[i].CustomerID == state.TreatedLoadIds[j].CustomerID {
+			return state.TreatedLoadIds[i].LoadID < state.TreatedLoadIds[j].LoadID
+		}
+		return state.TreatedLoadIds[i].CustomerID < state.TreatedLoadIds[j].CustomerID
+	})
+	for _, load := range logic.HeldLoads {
+		state.HeldLoads = append(state.HeldLoads, toHeldLoad(load))
+	}
+	sortHeldLoads(state.HeldLoads)
+	return state
+}
+
+// Restore replaces the current history by the one of a snapshot
+func (logic *FinanceLogic) Restore(state State) {
+	logic.mutex.Lock()
+	defer logic.mutex.Unlock()
+	logic.CustomersLoads = make(map[string][]Load, len(state.CustomersLoads))
+	logic.TreatedLoadIds = make(map[customerLoadID]interface{}, len(state.TreatedLoadIds))
+	logic.HeldLoads = make(map[customerLoadID]Load, len(state.HeldLoads))
+	logic.RejectedLoads = make(map[string][]Load, len(state.RejectedLoads))
+	logic.CustomerLocks = make(map[string]CustomerLock, len(state.Locks))
+	logic.CountedLoads = make(map[string][]CountedLoad, len(state.CountedLoads))
+	logic.Reservations = make(map[string][]Reservation, len(state.Reservations))
+	for customerID, loads := range state.CustomersLoads {
+		logic.CustomersLoads[customerID] = append([]Load(nil), loads...)
+	}
+	for customerID, loads := range state.RejectedLoads {
+		logic.RejectedLoads[customerID] = append([]Load(nil), loads...)
+	}
+	for customerID, loads := range state.CountedLoads {
+		logic.CountedLoads[customerID] = append([]CountedLoad(nil), loads...)
+	}
+	for customerID, reservations := range state.Reservations {
+		logic.Reservations[customerID] = append([]Reservation(nil), reservations...)
+	}
+	for _, lock := range state.Locks {
+		logic.CustomerLocks[lock.CustomerID] = lock
+	}
+	for _, id := range state.TreatedLoadIds {
+		logic.TreatedLoadIds[id] = nil
+	}
+	for _, held := range state.HeldLoads {
+		load := fromHeldLoad(held)
+		logic.HeldLoads[customerLoadID{LoadID: load.LoadID, CustomerID: load.CustomerID}] = load
+	}
+}
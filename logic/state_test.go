@@ -0,0 +1,57 @@
+package logic
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_SnapshotRestore(t *testing.T) {
+	lines := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$1500.00","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"3","customer_id":"2","load_amount":"$123.45","time":"2020-01-06T11:00:00Z"}`,
+		`{"id":"4","customer_id":"1","load_amount":"$3000.00","time":"2020-01-06T12:00:00Z"}`,
+		`{"id":"8","customer_id":"2","load_amount":"-$5.00","time":"2020-01-06T12:00:00Z"}`,
+	}
+	next := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2020-01-07T10:00:00Z"}`,
+		`{"id":"5","customer_id":"1","load_amount":"$1000.00","time":"2020-01-06T13:00:00Z"}`,
+		`{"id":"6","customer_id":"2","load_amount":"$4876.55","time":"2020-01-06T13:00:00Z"}`,
+		`{"id":"7","customer_id":"2","load_amount":"$0.01","time":"2020-01-06T14:00:00Z"}`,
+	}
+	original := NewFinanceLogic()
+	original.Policies[0].DayReviewMaxAmount = 6000
+	original.Policies[0].Counting.Rejected = LoadCounting{Count: true}
+	for _, line := range lines {
+		if _, err := original.ParseLoad(line); err != nil {
+			t.Fatalf("ParseLoad error %v", err)
+		}
+	}
+	stateJSON, err := json.Marshal(original.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal error %v", err)
+	}
+	if !strings.Contains(string(stateJSON), `"-$5.00"`) {
+		t.Errorf("Snapshot %s does not hold the negative rejected load", stateJSON)
+	}
+	var state State
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		t.Fatalf("Unmarshal error %v", err)
+	}
+	restored := NewFinanceLogic()
+	restored.Policies[0].DayReviewMaxAmount = 6000
+	restored.Policies[0].Counting.Rejected = LoadCounting{Count: true}
+	restored.Restore(state)
+	if !reflect.DeepEqual(restored.Snapshot(), original.Snapshot()) {
+		t.Errorf("Restore = %v, want %v", restored.Snapshot(), original.Snapshot())
+	}
+	for _, line := range next {
+		want, wantErr := original.ParseLoad(line)
+		got, gotErr := restored.ParseLoad(line)
+		if got != want || (gotErr != nil) != (wantErr != nil) {
+			t.Errorf("ParseLoad after Restore = %v and %v, want %v and %v", got, gotErr, want, wantErr)
+		}
+	}
+}
@@ -1,48 +1,312 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"github.com/vincentcreusot/finance-limits/audit"
 	"github.com/vincentcreusot/finance-limits/fileutils"
 	"github.com/vincentcreusot/finance-limits/logic"
+	"github.com/vincentcreusot/finance-limits/report"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// runOptions flags of the default validation run
+type runOptions struct {
+	inputFileName        string
+	inputFileNames       []string
+	sort                 bool
+	sortChunkLines       int
+	sortTempDir          string
+	outputFileName       string
+	auditFileName        string
+	follow               bool
+	offsetFileName       string
+	pollInterval         time.Duration
+	policyFileName       string
+	reviewFileName       string
+	lockFileName         string
+	groupsFileName       string
+	timeout              time.Duration
+	checkpointFileName   string
+	checkpointInterval   int
+	resume               bool
+	stdout               bool
+	acceptedFileName     string
+	rejectedFileName     string
+	heldFileName         string
+	errorFileName        string
+	noClobber            bool
+	outputDirName        string
+	partitionBy          string
+	customerBuckets      int
+	partitionMaxBytes    int64
+	summaryFileName      string
+	webhookURL           string
+	webhookSecret        string
+	webhookQueueFileName string
+	webhookAttempts      int
+	brokers              string
+	inputTopic           string
+	outputTopic          string
+	partition            int
+}
+
+// commands subcommands available in addition to the default validation run
+var commands = map[string]func(args []string){
+	"generate":     generateCommand,
+	"headroom":     headroomCommand,
+	"locks":        locksCommand,
+	"review":       reviewCommand,
+	"serve":        serveCommand,
+	"sort":         sortCommand,
+	"verify-audit": verifyAuditCommand,
+}
+
 func main() {
-	inputFileName := ""
-	outputFileName := ""
-	validateUsage(&inputFileName, &outputFileName)
-	lineToParseChannel := make(chan string)
-	go fileutils.ReadLines(inputFileName, lineToParseChannel)
+	if len(os.Args) > 1 {
+		if command, exist := commands[os.Args[1]]; exist {
+			command(os.Args[2:])
+			return
+		}
+	}
+	if !validateLoads() {
+		os.Exit(1)
+	}
+}
+
+// runContext gives a context cancelled on SIGINT or SIGTERM, and after the timeout if not 0
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case receivedSignal := <-signalChannel:
+			log.Println("Stopping on signal", receivedSignal)
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signalChannel)
+	}()
+	return ctx, cancel
+}
+
+// newEngine creates the engine with the policy and groups files if given
+func newEngine(policyFileName string, groupsFileName string) *logic.FinanceLogic {
 	parser := logic.NewFinanceLogic()
-	loadsToWrite, loadsErrors := parser.ParseLoads(lineToParseChannel)
-	if len(loadsErrors) > 0 {
-		for errCount, err := range loadsErrors {
-			log.Printf("Error #%d in load: %v\n", errCount, err)
+	if policyFileName != "" {
+		policies, err := logic.ReadPolicies(policyFileName)
+		if err != nil {
+			log.Fatalln("Error reading policy:", err)
+		}
+		parser.Policies = policies
+	}
+	if groupsFileName != "" {
+		groups, err := logic.ReadGroups(groupsFileName)
+		if err != nil {
+			log.Fatalln("Error reading groups:", err)
+		}
+		if err := parser.SetGroups(groups); err != nil {
+			log.Fatalln("Error in groups:", err)
+		}
+	}
+	return parser
+}
+
+// validateLoads validates the loads of the input file and writes the responses to the output file
+// it returns false if the run was interrupted before the end of the input
+func validateLoads() bool {
+	options := runOptions{}
+	validateUsage(&options)
+	ctx, cancel := runContext(options.timeout)
+	defer cancel()
+	if options.sort {
+		defer sortInput(ctx, &options)()
+	}
+	parser := newEngine(options.policyFileName, options.groupsFileName)
+	queue := restoreReviewQueue(options, parser)
+	locks := restoreLocks(options, parser)
+	sinks := openSinks(options)
+	defer closeSinks(sinks)
+	var auditLog *audit.Log
+	if options.auditFileName != "" {
+		var err error
+		auditLog, err = audit.Open(options.auditFileName)
+		if err != nil {
+			log.Fatalln("Error opening audit log:", err)
 		}
+		defer func() {
+			if err := auditLog.Close(); err != nil {
+				log.Println("Error closing audit log:", err)
+			}
+		}()
+		parser.Auditor = auditLog
+	}
+	if options.inputTopic != "" {
+		consumeLoads(ctx, options, parser, queue, locks, sinks, auditLog)
+		return true
 	}
-	if len(loadsToWrite) > 0 {
-		err := fileutils.WriteLines(outputFileName, loadsToWrite)
+	if options.follow {
+		followLoads(ctx, options, parser, queue, locks, sinks, auditLog)
+		return true
+	}
+	if options.checkpointFileName != "" {
+		return checkpointLoads(ctx, options, parser, queue, locks, sinks, auditLog)
+	}
+	outputs := createBatchOutputs(options)
+	summary := report.NewSummary(parser.Auditor)
+	parser.Auditor = summary
+	lineToParseChannel := make(chan string)
+	go fileutils.ReadMergedLines(ctx, options.inputFileNames, lineToParseChannel)
+	errCount := 0
+	parsedLines := parser.ParseLoadsEach(ctx, lineToParseChannel, func(line string, loadResponse string, err error) {
 		if err != nil {
-			log.Println("Error writing lines:", err)
+			log.Printf("Error #%d in load: %v\n", errCount, err)
+			errCount++
+			if err := outputs.writeError(line, err); err != nil {
+				log.Fatalln("Error writing error line:", err)
+			}
+		}
+		summary.AddResult(loadResponse, err)
+		if loadResponse != "" {
+			if err := outputs.writeResponse(line, loadResponse); err != nil {
+				log.Fatalln("Error writing line:", err)
+			}
+			sendDecision(ctx, sinks, loadResponse)
+		}
+	})
+	saveReviewQueue(options, parser, queue)
+	locks.save(parser)
+	if err := outputs.commit(); err != nil {
+		log.Println("Error writing lines:", err)
+	}
+	writeSummary(options, summary.Finish(parsedLines))
+	if ctx.Err() != nil {
+		log.Printf("Interrupted after %d lines with %d responses written: %v\n", parsedLines, outputs.written, ctx.Err())
+		return false
+	}
+	return true
+}
+
+// writeSummary writes the totals of the run to the standard error and to the summary file if given
+func writeSummary(options runOptions, runReport report.Report) {
+	if err := runReport.WriteText(os.Stderr); err != nil {
+		log.Println("Error writing summary:", err)
+	}
+	if options.summaryFileName != "" {
+		if err := runReport.WriteFile(options.summaryFileName); err != nil {
+			log.Println("Error writing summary file:", err)
 		}
 	}
 }
 
-func validateUsage(inputFileName *string, outputFileName *string) {
-	flag.StringVar(inputFileName, "inputFile", "", "File to parse")
-	flag.StringVar(inputFileName, "i", "", "File to parse")
-	flag.StringVar(outputFileName, "outputFile", "", "File to write to")
-	flag.StringVar(outputFileName, "o", "", "File to write to")
+func validateUsage(options *runOptions) {
+	flag.StringVar(&options.inputFileName, "inputFile", "", "File to parse, or comma separated files and glob patterns merged by time")
+	flag.StringVar(&options.inputFileName, "i", "", "File to parse, or comma separated files and glob patterns merged by time")
+	flag.StringVar(&options.outputFileName, "outputFile", "", "File to write to")
+	flag.StringVar(&options.outputFileName, "o", "", "File to write to")
+	flag.StringVar(&options.acceptedFileName, "acceptedFile", "", "File to also write the accepted responses to")
+	flag.StringVar(&options.rejectedFileName, "rejectedFile", "", "File to also write the rejected responses to")
+	flag.StringVar(&options.heldFileName, "heldFile", "", "File to also write the responses held for review to")
+	flag.StringVar(&options.errorFileName, "errorFile", "", "File to write the lines which could not be validated to")
+	flag.StringVar(&options.outputDirName, "outputDir", "", "Directory to write the responses to, partitioned with -partitionBy")
+	flag.StringVar(&options.partitionBy, "partitionBy", "date", "Comma separated partitions of -outputDir, date and customer")
+	flag.IntVar(&options.customerBuckets, "customerBuckets", 16, "Number of customer partitions of -outputDir")
+	flag.Int64Var(&options.partitionMaxBytes, "partitionMaxBytes", 0, "Size from which a partition file of -outputDir is continued in a new one, no limit if 0")
+	flag.StringVar(&options.summaryFileName, "summaryFile", "", "Json file to write the totals of the run to")
+	flag.BoolVar(&options.noClobber, "no-clobber", false, "Do not replace existing output files")
+	flag.StringVar(&options.auditFileName, "auditFile", "", "Audit log to append decisions to")
+	flag.StringVar(&options.policyFileName, "policyFile", "", "Json file with the limits to apply")
+	flag.StringVar(&options.reviewFileName, "reviewFile", "", "Queue of the loads held for review")
+	flag.StringVar(&options.lockFileName, "lockFile", "", "Locks of the customers kept between runs")
+	flag.StringVar(&options.groupsFileName, "groupsFile", "", "Json file grouping customers sharing limits")
+	flag.StringVar(&options.checkpointFileName, "checkpointFile", "", "File where to save checkpoints of the run")
+	flag.IntVar(&options.checkpointInterval, "checkpointInterval", 100000, "Number of lines between two checkpoints")
+	flag.BoolVar(&options.resume, "resume", false, "Resume the run from the last checkpoint")
+	flag.BoolVar(&options.sort, "sort", false, "Sort the input files by time before validating them")
+	flag.IntVar(&options.sortChunkLines, "sortChunkLines", fileutils.DefaultChunkLines, "Number of lines sorted in memory at once with -sort")
+	flag.StringVar(&options.sortTempDir, "sortTempDir", "", "Directory of the temporary files of -sort, the system one by default")
+	flag.BoolVar(&options.follow, "follow", false, "Keep reading lines appended to the input file")
+	flag.StringVar(&options.offsetFileName, "offsetFile", "", "File keeping the read offset of the followed input file")
+	flag.BoolVar(&options.stdout, "stdout", false, "Also write the responses to the standard output")
+	flag.StringVar(&options.webhookURL, "webhookURL", "", "Url each response is also posted to")
+	flag.StringVar(&options.webhookSecret, "webhookSecret", "", "Secret signing the webhook requests, "+webhookSecretVariable+" by default")
+	flag.StringVar(&options.webhookQueueFileName, "webhookQueue", "", "File keeping the responses not delivered to the webhook")
+	flag.IntVar(&options.webhookAttempts, "webhookAttempts", 5, "Number of attempts to deliver a response to the webhook")
+	flag.StringVar(&options.brokers, "brokers", "", "Comma separated Kafka brokers of the input and output topics")
+	flag.StringVar(&options.inputTopic, "inputTopic", "", "Kafka topic to consume the loads from instead of the input file")
+	flag.StringVar(&options.outputTopic, "outputTopic", "", "Kafka topic to produce the responses to")
+	flag.IntVar(&options.partition, "partition", 0, "Partition of the input and output topics")
+	flag.DurationVar(&options.timeout, "timeout", 0, "Maximum duration of the run, no limit if 0")
+	flag.DurationVar(&options.pollInterval, "pollInterval", time.Second, "Interval between checks for new lines in follow mode")
 	flag.Parse()
-	if *inputFileName == "" {
+	if options.checkpointInterval <= 0 {
+		fmt.Println("flag -checkpointInterval must be greater than 0")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if options.summaryFileName != "" && (options.follow || options.inputTopic != "") {
+		fmt.Println("flag -summaryFile cannot be used with -follow or -inputTopic")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if options.inputTopic != "" {
+		if options.brokers == "" || options.outputTopic == "" || options.checkpointFileName == "" {
+			fmt.Println("flags -brokers, -outputTopic and -checkpointFile are needed with -inputTopic")
+			flag.Usage()
+			os.Exit(1)
+		}
+		return
+	}
+	if options.inputFileName == "" {
 		fmt.Println("flag -inputFile is needed")
 		flag.Usage()
 		os.Exit(1)
 	}
-	if *outputFileName == "" {
-		fmt.Println("flag -outputFile is needed")
+	if options.outputFileName == "" && options.outputDirName == "" {
+		fmt.Println("flag -outputFile or -outputDir is needed")
+		flag.Usage()
+		os.Exit(1)
+	}
+	inputFileNames, err := fileutils.InputFiles(options.inputFileName)
+	if err != nil {
+		fmt.Println("Error in -inputFile:", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	options.inputFileNames = inputFileNames
+	if options.sort && options.follow {
+		fmt.Println("flag -sort cannot be used with -follow")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if len(inputFileNames) > 1 && !options.sort && (options.follow || options.checkpointFileName != "") {
+		fmt.Println("flags -follow and -checkpointFile need a single input file")
+		flag.Usage()
+		os.Exit(1)
+	}
+	options.inputFileName = inputFileNames[0]
+	splitOutput := options.acceptedFileName != "" || options.rejectedFileName != "" || options.heldFileName != "" ||
+		options.errorFileName != "" || options.outputDirName != ""
+	if splitOutput && (options.follow || options.checkpointFileName != "") {
+		fmt.Println("flags -acceptedFile, -rejectedFile, -heldFile, -errorFile and -outputDir cannot be used with -follow or -checkpointFile")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if fileName, exist := existingOutput(*options); options.noClobber && exist && !options.resume && !options.follow {
+		fmt.Println("output file", fileName, "already exists")
+		os.Exit(1)
+	}
+	if options.resume && options.checkpointFileName == "" {
+		fmt.Println("flag -checkpointFile is needed to resume")
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/partition"
+	"log"
+	"os"
+	"strings"
+)
+
+// errorRecord line of the error file, an input line which could not be validated
+type errorRecord struct {
+	Line  string `json:"line"`
+	Error string `json:"error"`
+}
+
+// batchOutputs files written by a batch run, each one to a temporary file renamed once the run is over
+type batchOutputs struct {
+	responses  *fileutils.AtomicWriter
+	accepted   *fileutils.AtomicWriter
+	rejected   *fileutils.AtomicWriter
+	held       *fileutils.AtomicWriter
+	errors     *fileutils.AtomicWriter
+	partitions *partition.Writer
+	written    int
+	errorLines int
+}
+
+// outputFileNames gives the files written by a run
+func outputFileNames(options runOptions) []string {
+	var fileNames []string
+	for _, fileName := range []string{options.outputFileName, options.acceptedFileName, options.rejectedFileName, options.heldFileName, options.errorFileName} {
+		if fileName != "" {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	return fileNames
+}
+
+// existingOutput gives the first output file or directory of a run which already exists
+func existingOutput(options runOptions) (string, bool) {
+	for _, fileName := range outputFileNames(options) {
+		if fileutils.FileExists(fileName) {
+			return fileName, true
+		}
+	}
+	if _, err := os.Stat(options.outputDirName); options.outputDirName != "" && err == nil {
+		return options.outputDirName, true
+	}
+	return "", false
+}
+
+// partitionScheme gives how the output directory is partitioned
+func partitionScheme(options runOptions) (partition.Scheme, error) {
+	scheme := partition.Scheme{MaxFileBytes: options.partitionMaxBytes}
+	for _, key := range strings.Split(options.partitionBy, ",") {
+		switch key {
+		case "":
+		case "date":
+			scheme.ByDate = true
+		case "customer":
+			scheme.CustomerBuckets = options.customerBuckets
+		default:
+			return scheme, fmt.Errorf("unknown partition %s, date or customer expected", key)
+		}
+	}
+	if scheme.CustomerBuckets < 0 {
+		return scheme, fmt.Errorf("number of customer buckets %d is negative", scheme.CustomerBuckets)
+	}
+	return scheme, nil
+}
+
+// createBatchOutputs creates the temporary files of the output files of a batch run, which with -no-clobber are not
+// renamed over files created meanwhile
+func createBatchOutputs(options runOptions) *batchOutputs {
+	outputs := &batchOutputs{}
+	if options.outputDirName != "" {
+		scheme, err := partitionScheme(options)
+		if err != nil {
+			log.Fatalln("Error in -partitionBy:", err)
+		}
+		if outputs.partitions, err = partition.NewWriter(options.outputDirName, scheme); err != nil {
+			log.Fatalln("Error creating output directory:", err)
+		}
+		outputs.partitions.NoClobber = options.noClobber
+	}
+	for _, output := range []struct {
+		fileName string
+		writer   **fileutils.AtomicWriter
+	}{
+		{options.outputFileName, &outputs.responses},
+		{options.acceptedFileName, &outputs.accepted},
+		{options.rejectedFileName, &outputs.rejected},
+		{options.heldFileName, &outputs.held},
+		{options.errorFileName, &outputs.errors},
+	} {
+		if output.fileName == "" {
+			continue
+		}
+		writer, err := fileutils.CreateAtomic(output.fileName)
+		if err != nil {
+			outputs.abort()
+			log.Fatalln("Error creating output file:", err)
+		}
+		writer.NoClobber = options.noClobber
+		*output.writer = writer
+	}
+	return outputs
+}
+
+// writeResponse writes the response of an input line to the output file or directory and to the accepted, rejected
+// or held file
+func (o *batchOutputs) writeResponse(line string, response string) error {
+	o.written++
+	if o.responses != nil {
+		if err := o.responses.WriteLine(response); err != nil {
+			return err
+		}
+	}
+	if o.partitions != nil {
+		if err := o.partitions.Write(line, response); err != nil {
+			return err
+		}
+	}
+	if o.accepted == nil && o.rejected == nil && o.held == nil {
+		return nil
+	}
+	var decision struct {
+		Accepted bool `json:"accepted"`
+		Pending  bool `json:"pending"`
+	}
+	if err := json.Unmarshal([]byte(response), &decision); err != nil {
+		return err
+	}
+	writer := o.rejected
+	if decision.Accepted {
+		writer = o.accepted
+	} else if decision.Pending {
+		writer = o.held
+	}
+	if writer == nil {
+		return nil
+	}
+	return writer.WriteLine(response)
+}
+
+// writeError writes an input line and its error to the error file
+func (o *batchOutputs) writeError(line string, loadErr error) error {
+	if o.errors == nil {
+		return nil
+	}
+	record, err := json.Marshal(errorRecord{Line: line, Error: loadErr.Error()})
+	if err != nil {
+		return err
+	}
+	o.errorLines++
+	return o.errors.WriteLine(string(record))
+}
+
+// commit renames the temporary files to the output files, leaving the response files untouched if there was no
+// response and the error file if there was neither a response nor an error, the files not committed yet being removed
+// on the first error
+func (o *batchOutputs) commit() error {
+	writers := o.responseWriters()
+	partitions := o.partitions
+	if o.written == 0 {
+		for _, writer := range writers {
+			writer.Abort()
+		}
+		if partitions != nil {
+			partitions.Abort()
+		}
+		writers, partitions = nil, nil
+	}
+	if o.errors != nil && o.written == 0 && o.errorLines == 0 {
+		o.errors.Abort()
+	} else if o.errors != nil {
+		writers = append(writers, o.errors)
+	}
+	for i, writer := range writers {
+		if err := writer.Commit(); err != nil {
+			for _, notCommitted := range writers[i+1:] {
+				notCommitted.Abort()
+			}
+			if partitions != nil {
+				partitions.Abort()
+			}
+			return fmt.Errorf("committing %d responses: %v", o.written, err)
+		}
+	}
+	if partitions != nil {
+		return partitions.Close()
+	}
+	return nil
+}
+
+// abort removes the temporary files
+func (o *batchOutputs) abort() {
+	for _, writer := range o.writers() {
+		writer.Abort()
+	}
+	if o.partitions != nil {
+		o.partitions.Abort()
+	}
+}
+
+// writers gives the writers of the output files of the run
+func (o *batchOutputs) writers() []*fileutils.AtomicWriter {
+	writers := o.responseWriters()
+	if o.errors != nil {
+		writers = append(writers, o.errors)
+	}
+	return writers
+}
+
+// responseWriters gives the writers of the output files of the run receiving responses
+func (o *batchOutputs) responseWriters() []*fileutils.AtomicWriter {
+	var writers []*fileutils.AtomicWriter
+	for _, writer := range []*fileutils.AtomicWriter{o.responses, o.accepted, o.rejected, o.held} {
+		if writer != nil {
+			writers = append(writers, writer)
+		}
+	}
+	return writers
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// dirEntries gives the names of the entries of a directory
+func dirEntries(t *testing.T, dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir error %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func Test_batchOutputsCommit(t *testing.T) {
+	tests := []struct {
+		name        string
+		responses   int
+		errors      int
+		existing    []string
+		wantErr     bool
+		wantEntries []string
+	}{
+		{name: "responses", responses: 2, errors: 1, wantEntries: []string{"accepted.txt", "errors.txt", "out", "output.txt"}},
+		{name: "onlyErrors", errors: 2, wantEntries: []string{"errors.txt"}},
+		{name: "nothing"},
+		{name: "clobbered", responses: 1, existing: []string{"accepted.txt"}, wantErr: true, wantEntries: []string{"accepted.txt", "output.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			options := runOptions{
+				outputFileName:   filepath.Join(dir, "output.txt"),
+				acceptedFileName: filepath.Join(dir, "accepted.txt"),
+				errorFileName:    filepath.Join(dir, "errors.txt"),
+				outputDirName:    filepath.Join(dir, "out"),
+				noClobber:        true,
+			}
+			outputs := createBatchOutputs(options)
+			for _, fileName := range tt.existing {
+				if err := ioutil.WriteFile(filepath.Join(dir, fileName), []byte("existing\n"), 0644); err != nil {
+					t.Fatalf("WriteFile error %v", err)
+				}
+			}
+			for i := 0; i < tt.responses; i++ {
+				line := `{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}`
+				if err := outputs.writeResponse(line, `{"id":"1","customer_id":"1","accepted":true}`); err != nil {
+					t.Fatalf("writeResponse error %v", err)
+				}
+			}
+			for i := 0; i < tt.errors; i++ {
+				if err := outputs.writeError("malformed", errors.New("malformed load")); err != nil {
+					t.Fatalf("writeError error %v", err)
+				}
+			}
+			if err := outputs.commit(); (err != nil) != tt.wantErr {
+				t.Errorf("commit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := dirEntries(t, dir); !reflect.DeepEqual(got, tt.wantEntries) {
+				t.Errorf("directory holds %v, want %v", got, tt.wantEntries)
+			}
+		})
+	}
+}
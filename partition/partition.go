@@ -0,0 +1,258 @@
+package partition
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestFileName name of the manifest in the output directory
+const ManifestFileName = "manifest.json"
+
+// unknownDate date partition of the decisions whose load has no time
+const unknownDate = "unknown"
+
+// Scheme how decisions are partitioned, a partition being split in several files when MaxFileBytes is not 0
+type Scheme struct {
+	ByDate          bool
+	CustomerBuckets int
+	MaxFileBytes    int64
+}
+
+// FileEntry one file of the manifest
+type FileEntry struct {
+	Path           string `json:"path"`
+	Date           string `json:"date,omitempty"`
+	CustomerBucket *int   `json:"customer_bucket,omitempty"`
+	Records        int    `json:"records"`
+	Bytes          int64  `json:"bytes"`
+}
+
+// Manifest list of the files of an output directory with their number of decisions
+type Manifest struct {
+	CreatedAt time.Time   `json:"created_at"`
+	Records   int         `json:"records"`
+	Files     []FileEntry `json:"files"`
+}
+
+// partitionFile file being written of a partition
+type partitionFile struct {
+	writer *fileutils.LineWriter
+	entry  FileEntry
+	part   int
+}
+
+// Writer writes decisions into a directory tree partitioned by load date and customer id hash
+// files are written to a staging directory which replaces the output directory on Close, with its manifest
+// with NoClobber, Close fails instead of replacing an existing output directory
+type Writer struct {
+	NoClobber  bool
+	dir        string
+	stagingDir string
+	scheme     Scheme
+	files      map[string]*partitionFile
+	parts      map[string]int
+	done       []FileEntry
+	now        func() time.Time
+}
+
+// NewWriter creates a writer of decisions partitioned in dir
+func NewWriter(dir string, scheme Scheme) (*Writer, error) {
+	dir = filepath.Clean(dir)
+	stagingDir, err := ioutil.TempDir(filepath.Dir(dir), filepath.Base(dir)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		dir:        dir,
+		stagingDir: stagingDir,
+		scheme:     scheme,
+		files:      make(map[string]*partitionFile),
+		parts:      make(map[string]int),
+		now:        time.Now,
+	}, nil
+}
+
+// Write writes the decision taken for an input line to its partition
+func (w *Writer) Write(line string, decision string) error {
+	var load struct {
+		Time time.Time `json:"time"`
+	}
+	_ = json.Unmarshal([]byte(line), &load) // a load without time goes to the unknown date
+	var decided struct {
+		CustomerID string `json:"customer_id"`
+	}
+	if err := json.Unmarshal([]byte(decision), &decided); err != nil {
+		return err
+	}
+	entry := w.partition(load.Time, decided.CustomerID)
+	file, err := w.file(entry)
+	if err != nil {
+		return err
+	}
+	if err := file.writer.WriteLine(decision); err != nil {
+		return err
+	}
+	file.entry.Records++
+	if w.scheme.MaxFileBytes > 0 && file.writer.Offset() >= w.scheme.MaxFileBytes {
+		return w.roll(entry.Path, file)
+	}
+	return nil
+}
+
+// Close closes the files, writes the manifest and replaces the output directory with the written one
+func (w *Writer) Close() error {
+	for _, file := range w.files {
+		if err := w.closeFile(file); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	w.files = make(map[string]*partitionFile)
+	sort.Slice(w.done, func(i, j int) bool {
+		return w.done[i].Path < w.done[j].Path
+	})
+	manifest := Manifest{CreatedAt: w.now().UTC(), Files: w.done}
+	if manifest.Files == nil {
+		manifest.Files = make([]FileEntry, 0)
+	}
+	for _, entry := range w.done {
+		manifest.Records += entry.Records
+	}
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	if err := fileutils.WriteFileAtomic(filepath.Join(w.stagingDir, ManifestFileName), content); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := os.Chmod(w.stagingDir, 0755); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.replaceDir()
+}
+
+// Abort removes the written files, the output directory keeping its previous content
+func (w *Writer) Abort() {
+	for _, file := range w.files {
+		file.writer.Close()
+	}
+	w.files = make(map[string]*partitionFile)
+	os.RemoveAll(w.stagingDir)
+}
+
+// ReadManifest reads the manifest of an output directory
+func ReadManifest(dir string) (Manifest, error) {
+	manifest := Manifest{}
+	content, err := ioutil.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(content, &manifest)
+	return manifest, err
+}
+
+// partition gives the entry of the partition of a decision, without its file
+func (w *Writer) partition(loadTime time.Time, customerID string) FileEntry {
+	entry := FileEntry{}
+	dir := ""
+	if w.scheme.ByDate {
+		entry.Date = unknownDate
+		if !loadTime.IsZero() {
+			entry.Date = loadTime.UTC().Format("2006-01-02")
+		}
+		dir = path.Join(dir, "date="+entry.Date)
+	}
+	if w.scheme.CustomerBuckets > 0 {
+		hash := fnv.New32a()
+		hash.Write([]byte(customerID))
+		bucket := int(hash.Sum32() % uint32(w.scheme.CustomerBuckets))
+		entry.CustomerBucket = &bucket
+		dir = path.Join(dir, fmt.Sprintf("customer_bucket=%d", bucket))
+	}
+	entry.Path = dir
+	return entry
+}
+
+// file gives the file being written of a partition, creating it if needed
+func (w *Writer) file(partition FileEntry) (*partitionFile, error) {
+	if file, exist := w.files[partition.Path]; exist {
+		return file, nil
+	}
+	return w.open(partition, w.parts[partition.Path])
+}
+
+// open creates a part file of a partition
+func (w *Writer) open(partition FileEntry, part int) (*partitionFile, error) {
+	entry := partition
+	entry.Path = path.Join(partition.Path, fmt.Sprintf("part-%d.jsonl", part))
+	fileName := filepath.Join(w.stagingDir, filepath.FromSlash(entry.Path))
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return nil, err
+	}
+	writer, err := fileutils.OpenLineWriter(fileName, false)
+	if err != nil {
+		return nil, err
+	}
+	file := &partitionFile{writer: writer, entry: entry, part: part}
+	w.files[partition.Path] = file
+	return file, nil
+}
+
+// roll closes a full file of a partition, the next decisions of the partition going to a new part file
+func (w *Writer) roll(partitionPath string, file *partitionFile) error {
+	delete(w.files, partitionPath)
+	w.parts[partitionPath] = file.part + 1
+	return w.closeFile(file)
+}
+
+// closeFile syncs and closes a file of a partition and adds it to the manifest
+func (w *Writer) closeFile(file *partitionFile) error {
+	if err := file.writer.Sync(); err != nil {
+		file.writer.Close()
+		return err
+	}
+	if err := file.writer.Close(); err != nil {
+		return err
+	}
+	file.entry.Bytes = file.writer.Offset()
+	w.done = append(w.done, file.entry)
+	return nil
+}
+
+// replaceDir renames the staging directory to the output directory, removing the previous one
+func (w *Writer) replaceDir() error {
+	previousDir := ""
+	if _, err := os.Stat(w.dir); err == nil && w.NoClobber {
+		w.Abort()
+		return fmt.Errorf("output directory %s already exists", w.dir)
+	}
+	if _, err := os.Stat(w.dir); err == nil {
+		previousDir = w.stagingDir + ".previous"
+		if err := os.Rename(w.dir, previousDir); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	if err := os.Rename(w.stagingDir, w.dir); err != nil {
+		if previousDir != "" {
+			os.Rename(previousDir, w.dir)
+		}
+		w.Abort()
+		return err
+	}
+	if previousDir != "" {
+		return os.RemoveAll(previousDir)
+	}
+	return nil
+}
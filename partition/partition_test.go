@@ -0,0 +1,129 @@
+package partition
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var records = [][2]string{
+	{`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T10:00:00Z"}`, `{"id":"1","customer_id":"1","accepted":true}`},
+	{`{"id":"2","customer_id":"2","load_amount":"$1.00","time":"2000-01-01T11:00:00Z"}`, `{"id":"2","customer_id":"2","accepted":true}`},
+	{`{"id":"3","customer_id":"1","load_amount":"$1.00","time":"2000-01-02T10:00:00Z"}`, `{"id":"3","customer_id":"1","accepted":false}`},
+	{`{"type":"release","id":"4","customer_id":"1"}`, `{"id":"4","customer_id":"1","accepted":false,"reservation":"released"}`},
+}
+
+func Test_Writer(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme Scheme
+		want   map[string][]string
+	}{
+		{
+			name:   "byDate",
+			scheme: Scheme{ByDate: true},
+			want: map[string][]string{
+				"date=2000-01-01/part-0.jsonl": {records[0][1], records[1][1]},
+				"date=2000-01-02/part-0.jsonl": {records[2][1]},
+				"date=unknown/part-0.jsonl":    {records[3][1]},
+			},
+		},
+		{
+			name:   "byDateAndCustomer",
+			scheme: Scheme{ByDate: true, CustomerBuckets: 4},
+			want: map[string][]string{
+				"date=2000-01-01/customer_bucket=0/part-0.jsonl": {records[0][1]},
+				"date=2000-01-01/customer_bucket=1/part-0.jsonl": {records[1][1]},
+				"date=2000-01-02/customer_bucket=0/part-0.jsonl": {records[2][1]},
+				"date=unknown/customer_bucket=0/part-0.jsonl":    {records[3][1]},
+			},
+		},
+		{
+			name:   "rollover",
+			scheme: Scheme{MaxFileBytes: 60},
+			want: map[string][]string{
+				"part-0.jsonl": {records[0][1], records[1][1]},
+				"part-1.jsonl": {records[2][1], records[3][1]},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "out")
+			if err := os.MkdirAll(filepath.Join(dir, "date=1999-12-31"), 0755); err != nil {
+				t.Fatalf("MkdirAll error %v", err)
+			}
+			writer, err := NewWriter(dir, tt.scheme)
+			if err != nil {
+				t.Fatalf("NewWriter error %v", err)
+			}
+			for _, record := range records {
+				if err := writer.Write(record[0], record[1]); err != nil {
+					t.Fatalf("Write error %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close error %v", err)
+			}
+			manifest, err := ReadManifest(dir)
+			if err != nil || manifest.Records != len(records) || len(manifest.Files) != len(tt.want) {
+				t.Fatalf("ReadManifest = %v and %v, want %d files", manifest, err, len(tt.want))
+			}
+			for _, entry := range manifest.Files {
+				content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+				lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+				if err != nil || !reflect.DeepEqual(lines, tt.want[entry.Path]) || entry.Records != len(lines) || entry.Bytes != int64(len(content)) {
+					t.Errorf("%s holds %v and %v with entry %v, want %v", entry.Path, lines, err, entry, tt.want[entry.Path])
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "date=1999-12-31")); !os.IsNotExist(err) {
+				t.Errorf("previous partition still exists")
+			}
+			if siblings, _ := ioutil.ReadDir(filepath.Dir(dir)); len(siblings) != 1 {
+				t.Errorf("%d directories next to the output, want the output only", len(siblings))
+			}
+		})
+	}
+}
+
+func Test_WriterAbort(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	writer, err := NewWriter(dir, Scheme{ByDate: true})
+	if err != nil {
+		t.Fatalf("NewWriter error %v", err)
+	}
+	if err := writer.Write(records[0][0], records[0][1]); err != nil {
+		t.Fatalf("Write error %v", err)
+	}
+	writer.Abort()
+	if siblings, _ := ioutil.ReadDir(filepath.Dir(dir)); len(siblings) != 0 {
+		t.Errorf("Abort left %d directories", len(siblings))
+	}
+}
+
+func Test_WriterNoClobber(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	writer, err := NewWriter(dir, Scheme{ByDate: true})
+	if err != nil {
+		t.Fatalf("NewWriter error %v", err)
+	}
+	writer.NoClobber = true
+	if err := writer.Write(records[0][0], records[0][1]); err != nil {
+		t.Fatalf("Write error %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "date=1999-12-31"), 0755); err != nil {
+		t.Fatalf("MkdirAll error %v", err)
+	}
+	if err := writer.Close(); err == nil {
+		t.Errorf("Close over an existing directory gives no error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "date=1999-12-31")); err != nil {
+		t.Errorf("existing directory was replaced: %v", err)
+	}
+	if siblings, _ := ioutil.ReadDir(filepath.Dir(dir)); len(siblings) != 1 {
+		t.Errorf("%d directories next to the output, want the existing one only", len(siblings))
+	}
+}
@@ -0,0 +1,211 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TopCustomers number of customers given in the top of the rejected customers
+const TopCustomers = 10
+
+// CustomerCount number of loads of a customer
+type CustomerCount struct {
+	CustomerID string `json:"customer_id"`
+	Count      int    `json:"count"`
+}
+
+// Report totals of a run, rejections being counted by level and rule failed
+// malformed lines are the lines without decision other than duplicates
+type Report struct {
+	StartedAt            time.Time       `json:"started_at"`
+	FinishedAt           time.Time       `json:"finished_at"`
+	DurationSeconds      float64         `json:"duration_seconds"`
+	LinesPerSecond       float64         `json:"lines_per_second"`
+	Lines                int             `json:"lines"`
+	Decisions            int             `json:"decisions"`
+	Accepted             int             `json:"accepted"`
+	Rejected             int             `json:"rejected"`
+	Pending              int             `json:"pending"`
+	Duplicates           int             `json:"duplicates"`
+	Malformed            int             `json:"malformed"`
+	Errors               int             `json:"errors"`
+	RejectionsByRule     map[string]int  `json:"rejections_by_rule"`
+	RequestedAmount      float64         `json:"requested_amount"`
+	AcceptedAmount       float64         `json:"accepted_amount"`
+	RejectedAmount       float64         `json:"rejected_amount"`
+	TopRejectedCustomers []CustomerCount `json:"top_rejected_customers"`
+}
+
+// Summary logic.DecisionAuditor gathering the totals of a run, the evidence being also given to the next auditor
+type Summary struct {
+	Next               logic.DecisionAuditor
+	mutex              sync.Mutex
+	report             Report
+	rejectedByCustomer map[string]int
+	now                func() time.Time
+}
+
+// NewSummary creates a summary of a run starting now
+func NewSummary(next logic.DecisionAuditor) *Summary {
+	summary := &Summary{
+		Next:               next,
+		rejectedByCustomer: make(map[string]int),
+		now:                time.Now,
+	}
+	summary.report.StartedAt = summary.now()
+	summary.report.RejectionsByRule = make(map[string]int)
+	return summary
+}
+
+// AuditDecision logic.DecisionAuditor implementation counting the decision
+func (s *Summary) AuditDecision(evidence logic.DecisionEvidence) error {
+	amount := evidence.RequestedAmount
+	if amount == 0 {
+		var load struct {
+			Amount logic.Amount `json:"load_amount"`
+		}
+		_ = json.Unmarshal(evidence.Input, &load) // a load with an invalid amount is counted without amount
+		amount = load.Amount.Value
+	}
+	s.mutex.Lock()
+	s.report.Decisions++
+	s.report.RequestedAmount += amount
+	switch {
+	case evidence.Accepted:
+		s.report.Accepted++
+		if evidence.AcceptedAmount != 0 {
+			s.report.AcceptedAmount += evidence.AcceptedAmount
+			s.report.RejectedAmount += amount - evidence.AcceptedAmount
+		} else {
+			s.report.AcceptedAmount += amount
+		}
+	case evidence.Pending:
+		s.report.Pending++
+	default:
+		s.report.Rejected++
+		s.report.RejectedAmount += amount
+		s.rejectedByCustomer[evidence.CustomerID]++
+		for _, outcome := range evidence.Rules {
+			if !outcome.Passed {
+				s.report.RejectionsByRule[outcome.Level+"."+outcome.Rule]++
+			}
+		}
+	}
+	s.mutex.Unlock()
+	if s.Next == nil {
+		return nil
+	}
+	return s.Next.AuditDecision(evidence)
+}
+
+// AddResult counts the result of a line without decision : a duplicate gives neither response nor error, a malformed
+// line an error without response, and a line with both a response and an error failed after its decision
+func (s *Summary) AddResult(loadResponse string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch {
+	case err == nil && loadResponse == "", err == logic.ErrDuplicateLoad:
+		s.report.Duplicates++
+	case err == nil:
+	case loadResponse == "":
+		s.report.Malformed++
+	default:
+		s.report.Errors++
+	}
+}
+
+// Finish gives the report of the run ending now after reading the given number of lines
+func (s *Summary) Finish(lines int) Report {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	report := s.report
+	report.Lines = lines
+	report.FinishedAt = s.now()
+	report.DurationSeconds = report.FinishedAt.Sub(report.StartedAt).Seconds()
+	if report.DurationSeconds > 0 {
+		report.LinesPerSecond = float64(lines) / report.DurationSeconds
+	}
+	report.RequestedAmount = roundCents(report.RequestedAmount)
+	report.AcceptedAmount = roundCents(report.AcceptedAmount)
+	report.RejectedAmount = roundCents(report.RejectedAmount)
+	report.RejectionsByRule = make(map[string]int, len(s.report.RejectionsByRule))
+	for rule, count := range s.report.RejectionsByRule {
+		report.RejectionsByRule[rule] = count
+	}
+	report.TopRejectedCustomers = topCustomers(s.rejectedByCustomer, TopCustomers)
+	return report
+}
+
+// WriteText writes the report in a human readable form
+func (r Report) WriteText(w io.Writer) error {
+	rules := make([]string, 0, len(r.RejectionsByRule))
+	for rule, count := range r.RejectionsByRule {
+		rules = append(rules, fmt.Sprintf("%s %d", rule, count))
+	}
+	sort.Strings(rules)
+	customers := make([]string, 0, len(r.TopRejectedCustomers))
+	for _, customer := range r.TopRejectedCustomers {
+		customers = append(customers, fmt.Sprintf("%s %d", customer.CustomerID, customer.Count))
+	}
+	_, err := fmt.Fprintf(w, "Processed %d lines in %s (%.0f lines/s)\n"+
+		"Decisions: %d, accepted %d, rejected %d, pending %d\n"+
+		"Not decided: %d duplicates, %d malformed, %d errors\n"+
+		"Amounts: requested $%.2f, accepted $%.2f, rejected $%.2f\n"+
+		"Rejections by rule: %s\n"+
+		"Top rejected customers: %s\n",
+		r.Lines, time.Duration(r.DurationSeconds*float64(time.Second)).Round(time.Millisecond), r.LinesPerSecond,
+		r.Decisions, r.Accepted, r.Rejected, r.Pending,
+		r.Duplicates, r.Malformed, r.Errors,
+		r.RequestedAmount, r.AcceptedAmount, r.RejectedAmount,
+		noneIfEmpty(strings.Join(rules, ", ")),
+		noneIfEmpty(strings.Join(customers, ", ")))
+	return err
+}
+
+// WriteFile writes the report as json
+func (r Report) WriteFile(filename string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomic(filename, append(content, '\n'))
+}
+
+// topCustomers gives the customers with the most loads, by id for the same count
+func topCustomers(counts map[string]int, top int) []CustomerCount {
+	customers := make([]CustomerCount, 0, len(counts))
+	for customerID, count := range counts {
+		customers = append(customers, CustomerCount{CustomerID: customerID, Count: count})
+	}
+	sort.Slice(customers, func(i, j int) bool {
+		if customers[i].Count == customers[j].Count {
+			return customers[i].CustomerID < customers[j].CustomerID
+		}
+		return customers[i].Count > customers[j].Count
+	})
+	if len(customers) > top {
+		customers = customers[:top]
+	}
+	return customers
+}
+
+// noneIfEmpty gives none for an empty list
+func noneIfEmpty(list string) string {
+	if list == "" {
+		return "none"
+	}
+	return list
+}
+
+// roundCents rounds a total to the cent, removing the floating point errors of the sums
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder auditor keeping the ids of the decisions it received
+type recorder struct {
+	loadIDs []string
+}
+
+func (r *recorder) AuditDecision(evidence logic.DecisionEvidence) error {
+	r.loadIDs = append(r.loadIDs, evidence.LoadID)
+	return nil
+}
+
+func Test_Summary(t *testing.T) {
+	next := &recorder{}
+	summary := NewSummary(next)
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	summary.report.StartedAt = start
+	summary.now = func() time.Time { return start.Add(2 * time.Second) }
+	parser := logic.NewFinanceLogic()
+	parser.Policies[0].RejectSubCentAmount = true
+	parser.Auditor = summary
+	lines := []string{
+		`{"id":"1","customer_id":"1","load_amount":"$3000.00","time":"2000-01-01T00:00:00Z"}`,
+		`{"id":"2","customer_id":"1","load_amount":"$3000.00","time":"2000-01-01T01:00:00Z"}`,
+		`{"id":"3","customer_id":"2","load_amount":"$6000.00","time":"2000-01-01T02:00:00Z"}`,
+		`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T03:00:00Z"}`,
+		`{"id":"4","customer_id":"1","load_amount":"$10.001","time":"2000-01-01T04:00:00Z"}`,
+		`{"id":"5"`,
+	}
+	for _, line := range lines {
+		loadResponse, err := parser.ParseLoad(line)
+		summary.AddResult(loadResponse, err)
+	}
+	summary.AddResult(`{"id":"6","customer_id":"1","accepted":true}`, errors.New("audit failed"))
+	got := summary.Finish(len(lines))
+	want := Report{
+		StartedAt:            start,
+		FinishedAt:           start.Add(2 * time.Second),
+		DurationSeconds:      2,
+		LinesPerSecond:       3,
+		Lines:                6,
+		Decisions:            4,
+		Accepted:             1,
+		Rejected:             3,
+		Duplicates:           1,
+		Malformed:            1,
+		Errors:               1,
+		RejectionsByRule:     map[string]int{"customer.day_amount": 2, "load.amount_precision": 1},
+		RequestedAmount:      12010,
+		AcceptedAmount:       3000,
+		RejectedAmount:       9010,
+		TopRejectedCustomers: []CustomerCount{{CustomerID: "1", Count: 2}, {CustomerID: "2", Count: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Finish() = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(next.loadIDs, []string{"1", "2", "3", "4"}) {
+		t.Errorf("next auditor received %v", next.loadIDs)
+	}
+	text := &bytes.Buffer{}
+	if err := got.WriteText(text); err != nil || !strings.Contains(text.String(), "customer.day_amount 2, load.amount_precision 1") {
+		t.Errorf("WriteText = %q and %v", text.String(), err)
+	}
+	fileName := filepath.Join(t.TempDir(), "summary.json")
+	if err := got.WriteFile(fileName); err != nil {
+		t.Fatalf("WriteFile error %v", err)
+	}
+	content, err := ioutil.ReadFile(fileName)
+	written := Report{}
+	if err != nil || json.Unmarshal(content, &written) != nil || !reflect.DeepEqual(written, want) {
+		t.Errorf("WriteFile wrote %s", content)
+	}
+}
+
+func Test_topCustomers(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+		top    int
+		want   []CustomerCount
+	}{
+		{"empty", map[string]int{}, 2, []CustomerCount{}},
+		{"byCountThenID", map[string]int{"b": 1, "a": 1, "c": 3}, 5, []CustomerCount{{"c", 3}, {"a", 1}, {"b", 1}}},
+		{"truncated", map[string]int{"b": 1, "a": 1, "c": 3}, 2, []CustomerCount{{"c", 3}, {"a", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topCustomers(tt.counts, tt.top); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("topCustomers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
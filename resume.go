@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/checkpoint"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"github.com/vincentcreusot/finance-limits/report"
+	"github.com/vincentcreusot/finance-limits/review"
+	"github.com/vincentcreusot/finance-limits/sink"
+	"log"
+)
+
+// checkpointLoads validates the loads of the input file writing each response as it comes and saving a checkpoint
+// regularly, so an interrupted run can be resumed from its last checkpoint, the output and the audit log being brought
+// back to the checkpoint so the lines processed after it are neither written nor audited twice, the summary giving the
+// totals of the lines processed since the run was started or resumed
+// it returns false if the run was interrupted before the end of the input
+func checkpointLoads(ctx context.Context, options runOptions, parser *logic.FinanceLogic, queue *review.Queue, locks *lockStore, sinks sink.Sink, auditLog *audit.Log) bool {
+	current := checkpoint.Checkpoint{}
+	restored := false
+	if options.resume {
+		current, restored = restoreCheckpoint(options.checkpointFileName, parser, queue, auditLog)
+	}
+	if !restored && options.noClobber && fileutils.FileExists(options.outputFileName) {
+		log.Fatalln("Error opening output file:", options.outputFileName, "already exists")
+	}
+	writer, err := fileutils.OpenLineWriterAt(options.outputFileName, current.OutputOffset)
+	if err != nil {
+		log.Fatalln("Error opening output file:", err)
+	}
+	defer func() {
+		if err := writer.Close(); err != nil {
+			log.Println("Error closing output file:", err)
+		}
+	}()
+	summary := report.NewSummary(parser.Auditor)
+	parser.Auditor = summary
+	lineChannel := make(chan fileutils.Line)
+	readErrors := make(chan error, 1)
+	go func() {
+		readErrors <- fileutils.ReadLinesFrom(ctx, options.inputFileName, current.Input, lineChannel)
+	}()
+	errCount := 0
+	parsedLines := 0
+	for line := range lineChannel {
+		loadResponse, err := parser.ParseLoad(line.Text)
+		if err != nil {
+			log.Printf("Error #%d in load: %v\n", errCount, err)
+			errCount++
+		}
+		summary.AddResult(loadResponse, err)
+		if loadResponse != "" {
+			if err := writer.WriteLine(loadResponse); err != nil {
+				log.Fatalln("Error writing line:", err)
+			}
+			sendDecision(ctx, sinks, loadResponse)
+		}
+		current.Input = line.Position
+		current.ParsedLines++
+		parsedLines++
+		if current.ParsedLines%options.checkpointInterval == 0 {
+			saveCheckpoint(options.checkpointFileName, options, parser, queue, locks, writer, auditLog, current)
+		}
+	}
+	if err := <-readErrors; err != nil {
+		log.Fatalln("Error reading input file:", err)
+	}
+	writeSummary(options, summary.Finish(parsedLines))
+	if ctx.Err() != nil {
+		saveCheckpoint(options.checkpointFileName, options, parser, queue, locks, writer, auditLog, current)
+		log.Printf("Interrupted after %d lines, checkpoint saved at offset %d: %v\n", current.ParsedLines, current.Input.Offset, ctx.Err())
+		return false
+	}
+	saveReviewQueue(options, parser, queue)
+	locks.save(parser)
+	if err := checkpoint.Remove(options.checkpointFileName); err != nil {
+		log.Println("Error removing checkpoint:", err)
+	}
+	return true
+}
+
+// restoreCheckpoint restores the engine history and the review queue from a checkpoint file, truncating the audit log
+// to the checkpoint, it returns false if there is no checkpoint
+func restoreCheckpoint(fileName string, parser *logic.FinanceLogic, queue *review.Queue, auditLog *audit.Log) (checkpoint.Checkpoint, bool) {
+	saved, exist, err := checkpoint.Load(fileName)
+	if err != nil {
+		log.Fatalln("Error reading checkpoint:", err)
+	}
+	if !exist {
+		return saved, false
+	}
+	if auditLog != nil && saved.Audit != nil {
+		if err := auditLog.Truncate(*saved.Audit); err != nil {
+			log.Fatalln("Error truncating audit log:", err)
+		}
+	}
+	parser.Restore(saved.State)
+	if queue != nil {
+		queue.Restore(parser)
+	}
+	log.Printf("Resuming after %d lines at offset %d\n", saved.ParsedLines, saved.Input.Offset)
+	return saved, true
+}
+
+// saveCheckpoint flushes the output and saves the position of the run and of the audit log with the engine history to a
+// checkpoint file
+func saveCheckpoint(fileName string, options runOptions, parser *logic.FinanceLogic, queue *review.Queue, locks *lockStore, writer *fileutils.LineWriter, auditLog *audit.Log, current checkpoint.Checkpoint) {
+	if err := writer.Flush(); err != nil {
+		log.Fatalln("Error writing lines:", err)
+	}
+	saveReviewQueue(options, parser, queue)
+	locks.save(parser)
+	current.OutputOffset = writer.Offset()
+	current.State = parser.Snapshot()
+	if auditLog != nil {
+		position, err := auditLog.Position()
+		if err != nil {
+			log.Fatalln("Error syncing audit log:", err)
+		}
+		current.Audit = &position
+	}
+	if err := checkpoint.Save(fileName, current); err != nil {
+		log.Fatalln("Error saving checkpoint:", err)
+	}
+}
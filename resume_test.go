@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"github.com/vincentcreusot/finance-limits/sink"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// decisionHook auditor calling a function with the number of decisions before giving each one to the audit log
+type decisionHook struct {
+	next      logic.DecisionAuditor
+	decisions int
+	hook      func(decisions int)
+}
+
+func (h *decisionHook) AuditDecision(evidence logic.DecisionEvidence) error {
+	h.decisions++
+	h.hook(h.decisions)
+	return h.next.AuditDecision(evidence)
+}
+
+// runCheckpointed validates the test input with checkpoints and an audit log, the hook being called on each decision
+func runCheckpointed(t *testing.T, options runOptions, hook func(decisions int, cancel func())) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	auditLog, err := audit.Open(options.auditFileName)
+	if err != nil {
+		t.Fatalf("Open error %v", err)
+	}
+	defer auditLog.Close()
+	parser := logic.NewFinanceLogic()
+	parser.Auditor = &decisionHook{next: auditLog, hook: func(decisions int) { hook(decisions, cancel) }}
+	return checkpointLoads(ctx, options, parser, nil, nil, sink.Multi{}, auditLog)
+}
+
+// auditedDecisions reads the decisions of an audit log, checking its hash chain
+func auditedDecisions(t *testing.T, filename string) []string {
+	if _, err := audit.Verify(filename); err != nil {
+		t.Fatalf("Verify %s error %v", filename, err)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Open error %v", err)
+	}
+	defer f.Close()
+	var decisions []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	for scanner.Scan() {
+		var record audit.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Unmarshal error %v", err)
+		}
+		decisions = append(decisions, string(record.Decision))
+	}
+	return decisions
+}
+
+func Test_checkpointLoadsResume(t *testing.T) {
+	dir := t.TempDir()
+	uninterrupted := runOptions{
+		inputFileName:      filepath.Join("test", "input.txt"),
+		outputFileName:     filepath.Join(dir, "uninterrupted.txt"),
+		auditFileName:      filepath.Join(dir, "uninterrupted.log"),
+		checkpointFileName: filepath.Join(dir, "uninterrupted.checkpoint"),
+		checkpointInterval: 100,
+	}
+	if !runCheckpointed(t, uninterrupted, func(int, func()) {}) {
+		t.Fatalf("uninterrupted run was interrupted")
+	}
+	options := runOptions{
+		inputFileName:      filepath.Join("test", "input.txt"),
+		outputFileName:     filepath.Join(dir, "output.txt"),
+		auditFileName:      filepath.Join(dir, "audit.log"),
+		checkpointFileName: filepath.Join(dir, "checkpoint.json"),
+		checkpointInterval: 100,
+	}
+	crashCheckpoint := filepath.Join(dir, "crash.json")
+	interrupted := runCheckpointed(t, options, func(decisions int, cancel func()) {
+		switch decisions {
+		case 450: // the checkpoint of line 400 is the last one before a crash at line 600
+			content, err := ioutil.ReadFile(options.checkpointFileName)
+			if err != nil {
+				t.Fatalf("ReadFile error %v", err)
+			}
+			if err := ioutil.WriteFile(crashCheckpoint, content, 0644); err != nil {
+				t.Fatalf("WriteFile error %v", err)
+			}
+		case 600:
+			cancel()
+		}
+	})
+	if interrupted {
+		t.Fatalf("run was not interrupted")
+	}
+	if err := os.Rename(crashCheckpoint, options.checkpointFileName); err != nil {
+		t.Fatalf("Rename error %v", err)
+	}
+	options.resume = true
+	if !runCheckpointed(t, options, func(int, func()) {}) {
+		t.Fatalf("resumed run was interrupted")
+	}
+	want, _ := ioutil.ReadFile(uninterrupted.outputFileName)
+	got, err := ioutil.ReadFile(options.outputFileName)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("resumed output differs from the uninterrupted one: %v", err)
+	}
+	wantDecisions := auditedDecisions(t, uninterrupted.auditFileName)
+	if gotDecisions := auditedDecisions(t, options.auditFileName); !reflect.DeepEqual(gotDecisions, wantDecisions) {
+		t.Errorf("resumed audit log holds %d decisions, want the %d of the uninterrupted run", len(gotDecisions), len(wantDecisions))
+	}
+	if _, err := os.Stat(options.checkpointFileName); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file still exists after the run: %v", err)
+	}
+}
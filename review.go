@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"github.com/vincentcreusot/finance-limits/review"
+	"log"
+	"os"
+	"time"
+)
+
+// restoreReviewQueue reads the review queue and gives its reviewed loads to the engine
+func restoreReviewQueue(options runOptions, parser *logic.FinanceLogic) *review.Queue {
+	if options.reviewFileName == "" {
+		return nil
+	}
+	queue, err := review.Load(options.reviewFileName)
+	if err != nil {
+		log.Fatalln("Error reading review queue:", err)
+	}
+	queue.Restore(parser)
+	return queue
+}
+
+// saveReviewQueue adds the newly held loads to the review queue file, keeping the reviews made during the run
+func saveReviewQueue(options runOptions, parser *logic.FinanceLogic, queue *review.Queue) {
+	if queue == nil {
+		return
+	}
+	if _, err := queue.Update(options.reviewFileName, parser.Held()); err != nil {
+		log.Println("Error saving review queue:", err)
+	}
+}
+
+// reviewCommand lists, approves or declines the loads of a review queue
+func reviewCommand(args []string) {
+	reviewFileName := ""
+	loadID := ""
+	customerID := ""
+	flags := flag.NewFlagSet("review", flag.ExitOnError)
+	flags.StringVar(&reviewFileName, "reviewFile", "", "Queue of the loads held for review")
+	flags.StringVar(&loadID, "id", "", "Id of the load to review")
+	flags.StringVar(&customerID, "customer_id", "", "Customer of the load to review")
+	flags.Usage = func() {
+		fmt.Println("Usage: review list|approve|decline -reviewFile file [-id id -customer_id id]")
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	action := args[0]
+	_ = flags.Parse(args[1:])
+	if reviewFileName == "" {
+		fmt.Println("flag -reviewFile is needed")
+		flags.Usage()
+		os.Exit(1)
+	}
+	queue, err := review.Load(reviewFileName)
+	if err != nil {
+		log.Fatalln("Error reading review queue:", err)
+	}
+	switch action {
+	case "list":
+		for _, item := range queue.Pending() {
+			fmt.Printf("%s\t%s\t$%.2f\t%s\n", item.LoadID, item.CustomerID, item.Amount, item.Time.Format(time.RFC3339))
+		}
+		return
+	case "approve":
+		err = queue.Approve(loadID, customerID, time.Now().UTC())
+	case "decline":
+		err = queue.Decline(loadID, customerID, time.Now().UTC())
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalln("Error reviewing load:", err)
+	}
+	if err := queue.Save(reviewFileName); err != nil {
+		log.Fatalln("Error saving review queue:", err)
+	}
+}
@@ -0,0 +1,149 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const (
+	// PendingStatus the load waits for a review
+	PendingStatus = "pending"
+	// ApprovedStatus the load was approved and counts in the customer history
+	ApprovedStatus = "approved"
+	// DeclinedStatus the load was declined
+	DeclinedStatus = "declined"
+)
+
+// Item a load of the review queue with its review status
+type Item struct {
+	logic.HeldLoad
+	Status     string     `json:"status"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Queue the held loads and their review, persisted as a json file
+type Queue struct {
+	Items []Item `json:"items"`
+}
+
+// Load reads a queue file, an empty queue if the file does not exist
+func Load(filename string) (*Queue, error) {
+	queue := &Queue{Items: make([]Item, 0)}
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, queue); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// Save writes the queue to a file, replacing it atomically
+func (q *Queue) Save(filename string) error {
+	content, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutils.WriteFileAtomic(filename, content)
+}
+
+// Restore gives the reviewed loads to the engine, pending loads stay held, approved ones count in history
+// and declined ones are no longer held
+func (q *Queue) Restore(parser *logic.FinanceLogic) {
+	for _, item := range q.Items {
+		switch item.Status {
+		case PendingStatus:
+			parser.HoldLoad(item.HeldLoad)
+		case ApprovedStatus:
+			parser.AddApproved(item.HeldLoad)
+		case DeclinedStatus:
+			_ = parser.DeclineHeld(item.LoadID, item.CustomerID) // only held if restored from a previous state
+		}
+	}
+}
+
+// Add appends the held loads not already in the queue, returns the number of loads added
+func (q *Queue) Add(held []logic.HeldLoad) int {
+	added := 0
+	for _, load := range held {
+		if q.find(load.LoadID, load.CustomerID) == nil {
+			q.Items = append(q.Items, Item{HeldLoad: load, Status: PendingStatus})
+			added++
+		}
+	}
+	return added
+}
+
+// Update adds the held loads not already in the queue to the queue file and saves it, the queue being read again
+// from the file first to keep the reviews made since it was loaded, returns the number of loads added
+func (q *Queue) Update(filename string, held []logic.HeldLoad) (int, error) {
+	if q.Add(held) == 0 {
+		return 0, nil
+	}
+	saved, err := Load(filename)
+	if err != nil {
+		return 0, err
+	}
+	added := saved.Add(held)
+	if added > 0 {
+		if err := saved.Save(filename); err != nil {
+			return 0, err
+		}
+	}
+	*q = *saved
+	return added, nil
+}
+
+// Pending lists the loads waiting for a review
+func (q *Queue) Pending() []Item {
+	pending := make([]Item, 0)
+	for _, item := range q.Items {
+		if item.Status == PendingStatus {
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
+
+// Approve approves a pending load
+func (q *Queue) Approve(loadID string, customerID string, reviewedAt time.Time) error {
+	return q.review(loadID, customerID, ApprovedStatus, reviewedAt)
+}
+
+// Decline declines a pending load
+func (q *Queue) Decline(loadID string, customerID string, reviewedAt time.Time) error {
+	return q.review(loadID, customerID, DeclinedStatus, reviewedAt)
+}
+
+// review sets the status of a pending load
+func (q *Queue) review(loadID string, customerID string, status string, reviewedAt time.Time) error {
+	item := q.find(loadID, customerID)
+	if item == nil {
+		return fmt.Errorf("load %s of customer %s is not in the review queue", loadID, customerID)
+	}
+	if item.Status != PendingStatus {
+		return fmt.Errorf("load %s of customer %s is already %s", loadID, customerID, item.Status)
+	}
+	item.Status = status
+	item.ReviewedAt = &reviewedAt
+	return nil
+}
+
+// find gives the item of a load, nil if not in the queue
+func (q *Queue) find(loadID string, customerID string) *Item {
+	for i := range q.Items {
+		if q.Items[i].LoadID == loadID && q.Items[i].CustomerID == customerID {
+			return &q.Items[i]
+		}
+	}
+	return nil
+}
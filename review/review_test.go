@@ -0,0 +1,126 @@
+package review
+
+import (
+	"github.com/vincentcreusot/finance-limits/logic"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_QueueReview(t *testing.T) {
+	reviewedAt := time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+	held := []logic.HeldLoad{
+		{LoadID: "1", CustomerID: "1", Amount: 5500, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)},
+		{LoadID: "2", CustomerID: "2", Amount: 5200, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)},
+	}
+	type output struct {
+		hasError bool
+		pending  int
+	}
+	tests := []struct {
+		name   string
+		review func(q *Queue) error
+		want   output
+	}{
+		{
+			name:   "approve",
+			review: func(q *Queue) error { return q.Approve("1", "1", reviewedAt) },
+			want:   output{hasError: false, pending: 1},
+		},
+		{
+			name:   "decline",
+			review: func(q *Queue) error { return q.Decline("2", "2", reviewedAt) },
+			want:   output{hasError: false, pending: 1},
+		},
+		{
+			name:   "notInQueue",
+			review: func(q *Queue) error { return q.Approve("1", "2", reviewedAt) },
+			want:   output{hasError: true, pending: 2},
+		},
+		{
+			name: "alreadyReviewed",
+			review: func(q *Queue) error {
+				if err := q.Decline("1", "1", reviewedAt); err != nil {
+					return nil
+				}
+				return q.Approve("1", "1", reviewedAt)
+			},
+			want: output{hasError: true, pending: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "queue.json")
+			queue, err := Load(filename)
+			if err != nil {
+				t.Fatalf("Load error %v", err)
+			}
+			if added := queue.Add(held); added != 2 {
+				t.Errorf("Add = %d, want 2", added)
+			}
+			if added := queue.Add(held); added != 0 {
+				t.Errorf("Add again = %d, want 0", added)
+			}
+			err = tt.review(queue)
+			if err := queue.Save(filename); err != nil {
+				t.Fatalf("Save error %v", err)
+			}
+			saved, loadErr := Load(filename)
+			if loadErr != nil {
+				t.Fatalf("Load error %v", loadErr)
+			}
+			if (err != nil) != tt.want.hasError || len(saved.Pending()) != tt.want.pending || !reflect.DeepEqual(saved, queue) {
+				t.Errorf("review = %v and %v, want %v", err, saved.Items, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Restore(t *testing.T) {
+	queue := &Queue{Items: []Item{
+		{HeldLoad: logic.HeldLoad{LoadID: "1", CustomerID: "1", Amount: 4000, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)}, Status: ApprovedStatus},
+		{HeldLoad: logic.HeldLoad{LoadID: "2", CustomerID: "1", Amount: 5500, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)}, Status: PendingStatus},
+		{HeldLoad: logic.HeldLoad{LoadID: "3", CustomerID: "1", Amount: 5500, Time: time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)}, Status: DeclinedStatus},
+	}}
+	parser := logic.NewFinanceLogic()
+	queue.Restore(parser)
+	if len(parser.CustomersLoads["1"]) != 1 || len(parser.Held()) != 1 {
+		t.Errorf("Restore gives history %v and held %v", parser.CustomersLoads["1"], parser.Held())
+	}
+	if response, _ := parser.ParseLoad(`{"id":"4","customer_id":"1","load_amount":"$1500.00","time":"2020-01-06T13:00:00Z"}`); response != `{"id":"4","customer_id":"1","accepted":false}` {
+		t.Errorf("ParseLoad = %v, want rejected with approved load in history", response)
+	}
+}
+
+func Test_Update(t *testing.T) {
+	reviewedAt := time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+	first := logic.HeldLoad{LoadID: "1", CustomerID: "1", Amount: 5500, Time: time.Date(2020, time.January, 6, 10, 0, 0, 0, time.UTC)}
+	second := logic.HeldLoad{LoadID: "2", CustomerID: "2", Amount: 5200, Time: time.Date(2020, time.January, 6, 11, 0, 0, 0, time.UTC)}
+	filename := filepath.Join(t.TempDir(), "queue.json")
+	running, _ := Load(filename)
+	if added, err := running.Update(filename, []logic.HeldLoad{first}); added != 1 || err != nil {
+		t.Fatalf("Update = %d and %v, want 1", added, err)
+	}
+	reviewer, _ := Load(filename)
+	if err := reviewer.Approve("1", "1", reviewedAt); err != nil {
+		t.Fatalf("Approve error %v", err)
+	}
+	if err := reviewer.Save(filename); err != nil {
+		t.Fatalf("Save error %v", err)
+	}
+	if added, err := running.Update(filename, []logic.HeldLoad{first}); added != 0 || err != nil {
+		t.Errorf("Update of a known load = %d and %v, want 0", added, err)
+	}
+	if added, err := running.Update(filename, []logic.HeldLoad{first, second}); added != 1 || err != nil {
+		t.Errorf("Update = %d and %v, want 1", added, err)
+	}
+	saved, _ := Load(filename)
+	want := []Item{
+		{HeldLoad: first, Status: ApprovedStatus, ReviewedAt: &reviewedAt},
+		{HeldLoad: second, Status: PendingStatus},
+	}
+	if !reflect.DeepEqual(saved.Items, want) || !reflect.DeepEqual(running, saved) {
+		t.Errorf("Update saved %v and keeps %v, want %v", saved.Items, running.Items, want)
+	}
+}
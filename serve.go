@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/server"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// adminTokenVariable environment variable holding the admin token when not given as flag
+const adminTokenVariable = "FINANCE_LIMITS_ADMIN_TOKEN"
+
+// serveCommand validates loads received over http until SIGINT or SIGTERM, reloading the policy on SIGHUP
+func serveCommand(args []string) {
+	address := ""
+	policyFileName := ""
+	groupsFileName := ""
+	auditFileName := ""
+	adminToken := ""
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.StringVar(&address, "addr", ":8080", "Address to listen on")
+	flags.StringVar(&policyFileName, "policyFile", "", "Json file with the limits to apply, reloaded on SIGHUP")
+	flags.StringVar(&groupsFileName, "groupsFile", "", "Json file grouping customers sharing limits")
+	flags.StringVar(&auditFileName, "auditFile", "", "Audit log to append decisions to")
+	flags.StringVar(&adminToken, "adminToken", "", "Bearer token of the admin routes, "+adminTokenVariable+" by default, admin routes refused without one")
+	_ = flags.Parse(args)
+	parser := newEngine(policyFileName, groupsFileName)
+	if auditFileName != "" {
+		auditLog, err := audit.Open(auditFileName)
+		if err != nil {
+			log.Fatalln("Error opening audit log:", err)
+		}
+		defer func() {
+			if err := auditLog.Close(); err != nil {
+				log.Println("Error closing audit log:", err)
+			}
+		}()
+		parser.Auditor = auditLog
+	}
+	if adminToken == "" {
+		adminToken = os.Getenv(adminTokenVariable)
+	}
+	loadServer := server.NewServer(parser, policyFileName, adminToken)
+	httpServer := &http.Server{Addr: address, Handler: loadServer}
+	ctx, cancel := runContext(0)
+	defer cancel()
+	go reloadOnHangup(ctx, loadServer)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Println("Error stopping server:", err)
+		}
+	}()
+	log.Println("Listening on", address)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Println("Error serving:", err)
+		return
+	}
+}
+
+// reloadOnHangup reloads the policy each time SIGHUP is received
+func reloadOnHangup(ctx context.Context, loadServer *server.Server) {
+	hangupChannel := make(chan os.Signal, 1)
+	signal.Notify(hangupChannel, syscall.SIGHUP)
+	defer signal.Stop(hangupChannel)
+	for {
+		select {
+		case <-hangupChannel:
+			if _, err := loadServer.ReloadPolicy(); err != nil {
+				log.Println("Error reloading policy, keeping the current one:", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// decisionResponse decision given by the server with the version of the policy used
+type decisionResponse struct {
+	logic.Decision
+	PolicyVersion string `json:"policy_version"`
+}
+
+// errorResponse body of a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server http interface of an engine
+type Server struct {
+	engine         *logic.FinanceLogic
+	policyFileName string
+	adminToken     string
+	mux            *http.ServeMux
+}
+
+// NewServer creates a Server validating loads with the engine, its policy being reloaded from the policy file
+// the admin routes need the admin token as bearer token, and are refused to everyone without an admin token
+func NewServer(engine *logic.FinanceLogic, policyFileName string, adminToken string) *Server {
+	s := &Server{
+		engine:         engine,
+		policyFileName: policyFileName,
+		adminToken:     adminToken,
+		mux:            http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/loads", s.handleLoad)
+	s.mux.HandleFunc("/reservations", s.handleReserve)
+	s.mux.HandleFunc("/reservations/capture", s.handleCapture)
+	s.mux.HandleFunc("/reservations/release", s.handleReleaseReservation)
+	s.mux.HandleFunc("/headroom", s.handleHeadroom)
+	s.mux.HandleFunc("/admin/policy", s.admin(s.handlePolicy))
+	s.mux.HandleFunc("/admin/policy/reload", s.admin(s.handleReload))
+	s.mux.HandleFunc("/admin/policy/rollback", s.admin(s.handleRollback))
+	s.mux.HandleFunc("/admin/locks", s.admin(s.handleLocks))
+	s.mux.HandleFunc("/admin/locks/release", s.admin(s.handleRelease))
+	return s
+}
+
+// admin lets through the requests giving the admin token as bearer token, the others getting 401
+func (s *Server) admin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if s.adminToken == "" || token == authorization || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "admin token missing or invalid"})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// ServeHTTP http.Handler implementation
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ReloadPolicy reads the policy file and applies it if valid, the current policy staying in place otherwise
+func (s *Server) ReloadPolicy() (logic.PolicyHistory, error) {
+	policies, err := logic.ReadPolicies(s.policyFileName)
+	if err != nil {
+		return s.engine.CurrentPolicies(), err
+	}
+	if err := s.engine.SetPolicies(policies); err != nil {
+		return s.engine.CurrentPolicies(), err
+	}
+	log.Println("Policy version", policies[len(policies)-1].Version, "applied")
+	return policies, nil
+}
+
+// handleLoad validates the load given as json body
+func (s *Server) handleLoad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	var load logic.Load
+	if err := json.NewDecoder(r.Body).Decode(&load); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	decision, err := s.engine.Validate(r.Context(), load)
+	if err == logic.ErrDuplicateLoad {
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil && decision.LoadID == "" {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Error auditing decision:", err)
+	}
+	writeJSON(w, http.StatusOK, decisionResponse{Decision: decision, PolicyVersion: decision.PolicyVersion})
+}
+
+// handleReserve validates the load given as json body and reserves it when accepted
+func (s *Server) handleReserve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	var load logic.Load
+	if err := json.NewDecoder(r.Body).Decode(&load); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	decision, err := s.engine.Reserve(r.Context(), load)
+	if err == logic.ErrDuplicateLoad {
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil && decision.LoadID == "" {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("Error auditing decision:", err)
+	}
+	writeJSON(w, http.StatusOK, decisionResponse{Decision: decision, PolicyVersion: decision.PolicyVersion})
+}
+
+// handleCapture captures the reserved load given by id, customer_id and time in the json body
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	s.handleReservationEvent(w, r, func(load logic.Load) (logic.Decision, error) {
+		err := s.engine.Capture(load.LoadID, load.CustomerID, load.Time)
+		return logic.Decision{LoadID: load.LoadID, CustomerID: load.CustomerID, Accepted: true, Reservation: logic.CapturedStatus}, err
+	})
+}
+
+// handleReleaseReservation releases the reserved load given by id and customer_id in the json body
+func (s *Server) handleReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	s.handleReservationEvent(w, r, func(load logic.Load) (logic.Decision, error) {
+		err := s.engine.Release(load.LoadID, load.CustomerID)
+		return logic.Decision{LoadID: load.LoadID, CustomerID: load.CustomerID, Reservation: logic.ReleasedStatus}, err
+	})
+}
+
+// handleReservationEvent applies a capture or a release to the reserved load of the json body
+func (s *Server) handleReservationEvent(w http.ResponseWriter, r *http.Request, apply func(logic.Load) (logic.Decision, error)) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	var load logic.Load
+	if err := json.NewDecoder(r.Body).Decode(&load); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	decision, err := apply(load)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, decision)
+}
+
+// handleHeadroom gives the headroom of the customer given as customer_id parameter, at the time given as time
+// parameter or now
+func (s *Server) handleHeadroom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	customerID := r.URL.Query().Get("customer_id")
+	if customerID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "customer_id is needed"})
+		return
+	}
+	at := time.Now().UTC()
+	if timeParam := r.URL.Query().Get("time"); timeParam != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, timeParam); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, s.engine.Headroom(customerID, at))
+}
+
+// handlePolicy gives the current policy versions
+func (s *Server) handlePolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.engine.CurrentPolicies())
+}
+
+// handleReload reloads the policy file
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	policy, err := s.ReloadPolicy()
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// handleRollback applies again the previous policy
+func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	policies, err := s.engine.RollbackPolicy()
+	if err != nil {
+		writeJSON(w, http.StatusConflict, errorResponse{Error: err.Error()})
+		return
+	}
+	log.Println("Policy rolled back to version", policies[len(policies)-1].Version)
+	writeJSON(w, http.StatusOK, policies)
+}
+
+// handleLocks gives the locks of customers
+func (s *Server) handleLocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.engine.Locks())
+}
+
+// handleRelease unlocks the customer given as customer_id parameter
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	customerID := r.URL.Query().Get("customer_id")
+	if err := s.engine.ReleaseLock(customerID); err != nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+		return
+	}
+	log.Println("Lock of customer", customerID, "released")
+	writeJSON(w, http.StatusOK, s.engine.Locks())
+}
+
+// writeJSON writes a json body with a status code
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"github.com/vincentcreusot/finance-limits/logic"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// adminToken token of the admin routes of the test servers
+const adminToken = "admin-token"
+
+// request sends a request to the server with the admin token and gives the status and the body
+func request(t *testing.T, s *Server, method string, path string, body string) (int, string) {
+	return requestAs(t, s, "Bearer "+adminToken, method, path, body)
+}
+
+// requestAs sends a request to the server with an authorization header if not empty and gives the status and the body
+func requestAs(t *testing.T, s *Server, authorization string, method string, path string, body string) (int, string) {
+	recorder := httptest.NewRecorder()
+	httpRequest := httptest.NewRequest(method, path, strings.NewReader(body))
+	if authorization != "" {
+		httpRequest.Header.Set("Authorization", authorization)
+	}
+	s.ServeHTTP(recorder, httpRequest)
+	return recorder.Code, strings.TrimSpace(recorder.Body.String())
+}
+
+func Test_handleLoad(t *testing.T) {
+	type output struct {
+		status int
+		body   string
+	}
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   output
+	}{
+		{
+			name:   "accepted",
+			method: http.MethodPost,
+			body:   `{"id":"2","customer_id":"1","load_amount":"$1000.00","time":"2020-01-06T11:00:00Z"}`,
+			want:   output{status: http.StatusOK, body: `{"id":"2","customer_id":"1","accepted":true,"policy_version":"1"}`},
+		},
+		{
+			name:   "rejected",
+			method: http.MethodPost,
+			body:   `{"id":"2","customer_id":"1","load_amount":"$1000.01","time":"2020-01-06T11:00:00Z"}`,
+			want:   output{status: http.StatusOK, body: `{"id":"2","customer_id":"1","accepted":false,"policy_version":"1"}`},
+		},
+		{
+			name:   "duplicate",
+			method: http.MethodPost,
+			body:   `{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2020-01-06T11:00:00Z"}`,
+			want:   output{status: http.StatusConflict, body: `{"error":"load already treated"}`},
+		},
+		{
+			name:   "malformed",
+			method: http.MethodPost,
+			body:   `{"id":"2"`,
+			want:   output{status: http.StatusBadRequest, body: `{"error":"unexpected EOF"}`},
+		},
+		{
+			name:   "wrongMethod",
+			method: http.MethodGet,
+			body:   ``,
+			want:   output{status: http.StatusMethodNotAllowed, body: `{"error":"method not allowed"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(logic.NewFinanceLogic(), "", adminToken)
+			request(t, s, http.MethodPost, "/loads", `{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`)
+			if status, body := request(t, s, tt.method, "/loads", tt.body); status != tt.want.status || body != tt.want.body {
+				t.Errorf("POST /loads = %d %s, want %v", status, body, tt.want)
+			}
+		})
+	}
+}
+
+func Test_policyReload(t *testing.T) {
+	policyFileName := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy := func(content string) {
+		if err := ioutil.WriteFile(policyFileName, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile error %v", err)
+		}
+	}
+	s := NewServer(logic.NewFinanceLogic(), policyFileName, adminToken)
+	load := `{"id":"%s","customer_id":"%s","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`
+	steps := []struct {
+		name   string
+		policy string
+		path   string
+		want   int
+		load   string
+		result string
+	}{
+		{
+			name: "reload", policy: `{"version":"2","day_max_amount":3000}`, path: "/admin/policy/reload", want: http.StatusOK,
+			load: strings.Replace(strings.Replace(load, "%s", "1", 1), "%s", "1", 1), result: `{"id":"1","customer_id":"1","accepted":false,"policy_version":"2"}`,
+		},
+		{
+			name: "invalidReload", policy: `{"version":"3","day_max_amount":-1}`, path: "/admin/policy/reload", want: http.StatusUnprocessableEntity,
+			load: strings.Replace(strings.Replace(load, "%s", "2", 1), "%s", "2", 1), result: `{"id":"2","customer_id":"2","accepted":false,"policy_version":"2"}`,
+		},
+		{
+			name: "rollback", policy: ``, path: "/admin/policy/rollback", want: http.StatusOK,
+			load: strings.Replace(strings.Replace(load, "%s", "3", 1), "%s", "3", 1), result: `{"id":"3","customer_id":"3","accepted":true,"policy_version":"1"}`,
+		},
+		{
+			name: "rollbackWithoutPrevious", policy: ``, path: "/admin/policy/rollback", want: http.StatusConflict,
+			load: strings.Replace(strings.Replace(load, "%s", "4", 1), "%s", "4", 1), result: `{"id":"4","customer_id":"4","accepted":true,"policy_version":"1"}`,
+		},
+	}
+	for _, step := range steps {
+		if step.policy != "" {
+			writePolicy(step.policy)
+		}
+		if status, body := request(t, s, http.MethodPost, step.path, ""); status != step.want {
+			t.Errorf("%s: POST %s = %d %s, want %d", step.name, step.path, status, body, step.want)
+		}
+		if _, body := request(t, s, http.MethodPost, "/loads", step.load); body != step.result {
+			t.Errorf("%s: POST /loads = %s, want %s", step.name, body, step.result)
+		}
+	}
+	if status, body := request(t, s, http.MethodGet, "/admin/policy", ""); status != http.StatusOK || !strings.Contains(body, `"version":"1"`) {
+		t.Errorf("GET /admin/policy = %d %s, want version 1", status, body)
+	}
+}
+
+func Test_locks(t *testing.T) {
+	engine := logic.NewFinanceLogic()
+	engine.SetLock(logic.CustomerLock{
+		CustomerID:  "1",
+		LockedFrom:  time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC),
+		LockedUntil: time.Date(2020, time.January, 7, 0, 0, 0, 0, time.UTC),
+	})
+	s := NewServer(engine, "", adminToken)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{
+			name: "list", method: http.MethodGet, path: "/admin/locks", status: http.StatusOK,
+			body: `[{"customer_id":"1","locked_from":"2020-01-06T00:00:00Z","locked_until":"2020-01-07T00:00:00Z"}]`,
+		},
+		{
+			name: "lockedLoad", method: http.MethodPost, path: "/loads", status: http.StatusOK,
+			body: `{"id":"1","customer_id":"1","accepted":false,"rejection_reasons":["lockout"],"locked_until":"2020-01-07T00:00:00Z","policy_version":"1"}`,
+		},
+		{name: "release", method: http.MethodPost, path: "/admin/locks/release?customer_id=1", status: http.StatusOK, body: `[]`},
+		{
+			name: "releaseUnlocked", method: http.MethodPost, path: "/admin/locks/release?customer_id=1", status: http.StatusNotFound,
+			body: `{"error":"customer 1 is not locked"}`,
+		},
+	}
+	for _, tt := range tests {
+		load := `{"id":"1","customer_id":"1","load_amount":"$10.00","time":"2020-01-06T10:00:00Z"}`
+		if status, body := request(t, s, tt.method, tt.path, load); status != tt.status || body != tt.body {
+			t.Errorf("%s: %s %s = %d %s, want %d %s", tt.name, tt.method, tt.path, status, body, tt.status, tt.body)
+		}
+	}
+}
+
+func Test_handleHeadroom(t *testing.T) {
+	s := NewServer(logic.NewFinanceLogic(), "", adminToken)
+	request(t, s, http.MethodPost, "/loads", `{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`)
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+	}{
+		{
+			name:   "sameDay",
+			path:   "/headroom?customer_id=1&time=2020-01-06T12:00:00Z",
+			status: http.StatusOK,
+			body: `{"customer_id":"1","time":"2020-01-06T12:00:00Z","policy_version":"1","amount":1000,"windows":[` +
+				`{"rule":"day_amount","level":"customer","limit":5000,"used":4000,"remaining":1000},` +
+				`{"rule":"day_count","level":"customer","limit":3,"used":1,"remaining":2},` +
+				`{"rule":"week_amount","level":"customer","limit":20000,"used":4000,"remaining":16000}]}`,
+		},
+		{name: "noCustomer", path: "/headroom?time=2020-01-06T12:00:00Z", status: http.StatusBadRequest, body: `{"error":"customer_id is needed"}`},
+		{name: "badTime", path: "/headroom?customer_id=1&time=yesterday", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := request(t, s, http.MethodGet, tt.path, "")
+			if status != tt.status || (tt.body != "" && body != tt.body) {
+				t.Errorf("GET %s = %d %s, want %d %s", tt.path, status, body, tt.status, tt.body)
+			}
+		})
+	}
+}
+
+func Test_reservations(t *testing.T) {
+	engine := logic.NewFinanceLogic()
+	engine.Policies[0].ReservationTTL = logic.Duration{Duration: time.Hour}
+	s := NewServer(engine, "", adminToken)
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name: "reserve", path: "/reservations", status: http.StatusOK,
+			body: `{"id":"1","customer_id":"1","load_amount":"$4000.00","time":"2020-01-06T10:00:00Z"}`,
+			want: `{"id":"1","customer_id":"1","accepted":true,"reservation":"reserved","policy_version":"1"}`,
+		},
+		{
+			name: "reserveOverLimit", path: "/reservations", status: http.StatusOK,
+			body: `{"id":"2","customer_id":"1","load_amount":"$2000.00","time":"2020-01-06T10:10:00Z"}`,
+			want: `{"id":"2","customer_id":"1","accepted":false,"policy_version":"1"}`,
+		},
+		{
+			name: "capture", path: "/reservations/capture", status: http.StatusOK,
+			body: `{"id":"1","customer_id":"1","time":"2020-01-06T10:30:00Z"}`,
+			want: `{"id":"1","customer_id":"1","accepted":true,"reservation":"captured"}`,
+		},
+		{
+			name: "reserveAgain", path: "/reservations", status: http.StatusOK,
+			body: `{"id":"3","customer_id":"1","load_amount":"$500.00","time":"2020-01-06T11:00:00Z"}`,
+			want: `{"id":"3","customer_id":"1","accepted":true,"reservation":"reserved","policy_version":"1"}`,
+		},
+		{
+			name: "captureExpired", path: "/reservations/capture", status: http.StatusConflict,
+			body: `{"id":"3","customer_id":"1","time":"2020-01-06T12:00:00Z"}`,
+			want: `{"error":"reservation of load 3 of customer 1 expired at 2020-01-06T12:00:00Z"}`,
+		},
+		{
+			name: "captureWithoutTime", path: "/reservations/capture", status: http.StatusConflict,
+			body: `{"id":"3","customer_id":"1"}`,
+			want: `{"error":"capture of load 3 of customer 1 has no time"}`,
+		},
+		{
+			name: "releaseExpired", path: "/reservations/release", status: http.StatusOK,
+			body: `{"id":"3","customer_id":"1","time":"2020-01-06T12:00:00Z"}`,
+			want: `{"id":"3","customer_id":"1","accepted":false,"reservation":"released"}`,
+		},
+		{
+			name: "releaseUnknown", path: "/reservations/release", status: http.StatusConflict,
+			body: `{"id":"3","customer_id":"1","time":"2020-01-06T12:00:00Z"}`,
+			want: `{"error":"load 3 of customer 1 is not reserved"}`,
+		},
+		{name: "badBody", path: "/reservations/release", status: http.StatusBadRequest, body: `{`},
+	}
+	for _, tt := range tests {
+		if status, body := request(t, s, http.MethodPost, tt.path, tt.body); status != tt.status || (tt.want != "" && body != tt.want) {
+			t.Errorf("%s: POST %s = %d %s, want %d %s", tt.name, tt.path, status, body, tt.status, tt.want)
+		}
+	}
+}
+
+func Test_adminAuthorization(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+		authorized    bool
+	}{
+		{name: "authorized", adminToken: adminToken, authorization: "Bearer " + adminToken, authorized: true},
+		{name: "noAuthorization", adminToken: adminToken},
+		{name: "wrongToken", adminToken: adminToken, authorization: "Bearer other"},
+		{name: "notBearer", adminToken: adminToken, authorization: adminToken},
+		{name: "noAdminToken", authorization: "Bearer "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := logic.NewFinanceLogic()
+			engine.SetLock(logic.CustomerLock{
+				CustomerID:  "1",
+				LockedFrom:  time.Date(2020, time.January, 6, 0, 0, 0, 0, time.UTC),
+				LockedUntil: time.Date(2020, time.January, 7, 0, 0, 0, 0, time.UTC),
+			})
+			s := NewServer(engine, "", tt.adminToken)
+			for _, path := range []string{"/admin/policy", "/admin/policy/reload", "/admin/policy/rollback", "/admin/locks", "/admin/locks/release?customer_id=1"} {
+				method := http.MethodPost
+				if path == "/admin/policy" || path == "/admin/locks" {
+					method = http.MethodGet
+				}
+				if status, body := requestAs(t, s, tt.authorization, method, path, ""); (status != http.StatusUnauthorized) != tt.authorized {
+					t.Errorf("%s %s = %d %s, want authorized %v", method, path, status, body, tt.authorized)
+				}
+			}
+			if locks := engine.Locks(); !tt.authorized && len(locks) != 1 {
+				t.Errorf("engine holds %d locks, want the lock kept", len(locks))
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Sink receives each decision as a json line
+type Sink interface {
+	Send(ctx context.Context, line string) error
+	Close() error
+}
+
+// File sink writing decisions to a file
+type File struct {
+	writer *fileutils.LineWriter
+}
+
+// NewFile creates a sink replacing the content of a file with the decisions
+func NewFile(filename string) (*File, error) {
+	writer, err := fileutils.OpenLineWriter(filename, false)
+	if err != nil {
+		return nil, err
+	}
+	return &File{writer: writer}, nil
+}
+
+// Send writes a decision to the buffer of the file
+func (f *File) Send(ctx context.Context, line string) error {
+	return f.writer.WriteLine(line)
+}
+
+// Close writes the buffered decisions and closes the file
+func (f *File) Close() error {
+	return f.writer.Close()
+}
+
+// Writer sink writing decisions to a writer such as the standard output
+type Writer struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewWriter creates a sink writing decisions to a writer
+func NewWriter(writer io.Writer) *Writer {
+	return &Writer{writer: writer}
+}
+
+// NewStdout creates a sink writing decisions to the standard output
+func NewStdout() *Writer {
+	return NewWriter(os.Stdout)
+}
+
+// Send writes a decision followed by a new line
+func (w *Writer) Send(ctx context.Context, line string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, err := fmt.Fprintln(w.writer, line)
+	return err
+}
+
+// Close does nothing, the writer being owned by the caller
+func (w *Writer) Close() error {
+	return nil
+}
+
+// Multi sink sending each decision to all its sinks
+type Multi []Sink
+
+// Send sends a decision to every sink, even when some of them fail
+func (m Multi) Send(ctx context.Context, line string) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Send(ctx, line); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// Close closes every sink, even when some of them fail
+func (m Multi) Close() error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// joinErrors gives one error holding the messages of several ones, nil if there is none
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("%d sinks failed: %s", len(errs), strings.Join(messages, "; "))
+}
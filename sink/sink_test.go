@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// failingSink sink failing every call
+type failingSink struct{}
+
+func (failingSink) Send(ctx context.Context, line string) error { return errors.New("send failed") }
+func (failingSink) Close() error                                { return errors.New("close failed") }
+
+func Test_Multi(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "out.txt")
+	file, err := NewFile(fileName)
+	if err != nil {
+		t.Fatalf("NewFile error %v", err)
+	}
+	var buffer bytes.Buffer
+	tests := []struct {
+		name    string
+		sinks   Multi
+		wantErr string
+	}{
+		{name: "allSinks", sinks: Multi{file, NewWriter(&buffer)}},
+		{name: "oneFailing", sinks: Multi{failingSink{}}, wantErr: "send failed"},
+		{name: "twoFailing", sinks: Multi{failingSink{}, failingSink{}}, wantErr: "2 sinks failed: send failed; send failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sinks.Send(context.Background(), `{"id":"1"}`)
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("Send error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+	if err := (Multi{file, NewWriter(&buffer)}).Close(); err != nil {
+		t.Fatalf("Close error %v", err)
+	}
+	written, err := ioutil.ReadFile(fileName)
+	if err != nil || string(written) != "{\"id\":\"1\"}\n" || buffer.String() != "{\"id\":\"1\"}\n" {
+		t.Errorf("written %q and %q with %v, want one decision", written, buffer.String(), err)
+	}
+}
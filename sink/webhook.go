@@ -0,0 +1,351 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SignatureHeader header holding the hmac sha256 of the body signed with the secret of the webhook
+	SignatureHeader = "X-Signature-256"
+
+	defaultAttempts   = 5
+	defaultBackoff    = 200 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+	defaultTimeout    = 10 * time.Second
+	defaultBufferSize = 1024
+)
+
+// Webhook sink posting each decision to an url from a background goroutine, retrying with an exponential backoff
+// decisions not delivered after all attempts are appended to a queue file, the next ones being queued behind them
+// while the queued ones are retried, so decisions are delivered in order without slowing down their sender
+type Webhook struct {
+	URL           string
+	Secret        string
+	QueueFileName string
+	Attempts      int
+	Backoff       time.Duration
+	MaxBackoff    time.Duration
+	Client        *http.Client
+	BufferSize    int
+	OnError       func(line string, err error)
+	start         sync.Once
+	lines         chan string
+	done          chan struct{}
+	mutex         sync.Mutex
+	queue         []string
+	queueFile     *os.File
+	queueLines    int
+	pending       int
+}
+
+// statusError response of the webhook with an unexpected status
+type statusError struct {
+	status    int
+	permanent bool
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("webhook answered with status %d", e.status)
+}
+
+// NewWebhook creates a webhook sink with the default retries, reading the decisions left in the queue file if given
+func NewWebhook(url string, secret string, queueFileName string) (*Webhook, error) {
+	queue, err := readQueue(queueFileName)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{
+		URL:           url,
+		Secret:        secret,
+		QueueFileName: queueFileName,
+		Attempts:      defaultAttempts,
+		Backoff:       defaultBackoff,
+		MaxBackoff:    defaultMaxBackoff,
+		Client:        &http.Client{Timeout: defaultTimeout},
+		BufferSize:    defaultBufferSize,
+		queue:         queue,
+		queueLines:    len(queue),
+		pending:       len(queue),
+	}, nil
+}
+
+// Sign gives the value of the signature header of a body, sha256= followed by the hex hmac of the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send gives a decision to the delivery goroutine, waiting only while its buffer of BufferSize decisions is full and
+// the context is not done, the decision being dropped then
+// a decision refused by the webhook with a client error is not queued and is given to OnError
+func (w *Webhook) Send(ctx context.Context, line string) error {
+	w.start.Do(w.run)
+	w.mutex.Lock()
+	w.pending++
+	w.mutex.Unlock()
+	select {
+	case w.lines <- line: // a decision is still buffered when there is room, even once the context is done
+		return nil
+	default:
+	}
+	select {
+	case w.lines <- line:
+		return nil
+	case <-ctx.Done():
+		w.mutex.Lock()
+		w.pending--
+		w.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Pending gives the number of decisions not delivered yet
+func (w *Webhook) Pending() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.pending
+}
+
+// Close waits for the decisions sent to be delivered or queued, then tries to deliver the queued decisions with all
+// the attempts, those still failing staying in the queue file
+func (w *Webhook) Close() error {
+	w.start.Do(w.run)
+	close(w.lines)
+	<-w.done
+	err := w.redeliver(w.Attempts)
+	if closeErr := w.closeQueue(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if pending := w.Pending(); pending > 0 {
+		return fmt.Errorf("%d decisions not delivered to %s", pending, w.URL)
+	}
+	return nil
+}
+
+// run starts the delivery goroutine
+func (w *Webhook) run() {
+	bufferSize := w.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	w.lines = make(chan string, bufferSize)
+	w.done = make(chan struct{})
+	go w.deliverLines()
+}
+
+// deliverLines delivers the sent decisions until the sink is closed : while the queue is empty each decision is
+// delivered with all the attempts, otherwise decisions are queued and the oldest one is tried again after a backoff
+func (w *Webhook) deliverLines() {
+	defer close(w.done)
+	backoff := w.Backoff
+	for {
+		if len(w.queue) == 0 {
+			line, open := <-w.lines
+			if !open {
+				return
+			}
+			if err := w.deliver(line, w.Attempts); err != nil && !permanent(err) {
+				w.enqueue(line)
+				continue
+			}
+			w.delivered(1)
+			continue
+		}
+		select {
+		case line, open := <-w.lines:
+			if !open {
+				return
+			}
+			w.enqueue(line)
+		case <-time.After(backoff):
+			queued := len(w.queue)
+			if err := w.redeliver(1); err != nil {
+				w.report("", err)
+			}
+			if len(w.queue) < queued {
+				backoff = w.Backoff
+				continue
+			}
+			backoff *= 2
+			if backoff > w.MaxBackoff {
+				backoff = w.MaxBackoff
+			}
+		}
+	}
+}
+
+// redeliver posts the queued decisions in order until one fails, dropping those refused with a client error, and
+// removes them from the queue file
+func (w *Webhook) redeliver(attempts int) error {
+	done := 0
+	for _, line := range w.queue {
+		if err := w.deliver(line, attempts); err != nil && !permanent(err) {
+			break
+		}
+		done++
+	}
+	if done == 0 {
+		return nil
+	}
+	w.queue = w.queue[done:]
+	w.delivered(done)
+	return w.compactQueue()
+}
+
+// deliver posts a decision up to a number of attempts, waiting twice longer after each failure, a decision refused
+// being given to OnError
+func (w *Webhook) deliver(line string, attempts int) error {
+	backoff := w.Backoff
+	for attempt := 1; ; attempt++ {
+		err := w.post(context.Background(), line)
+		if permanent(err) {
+			w.report(line, err)
+		}
+		if err == nil || permanent(err) || attempt >= attempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > w.MaxBackoff {
+			backoff = w.MaxBackoff
+		}
+	}
+}
+
+// delivered counts decisions delivered or refused
+func (w *Webhook) delivered(count int) {
+	w.mutex.Lock()
+	w.pending -= count
+	w.mutex.Unlock()
+}
+
+// report gives an error to OnError if set
+func (w *Webhook) report(line string, err error) {
+	if w.OnError != nil {
+		w.OnError(line, err)
+	}
+}
+
+// post sends one signed request with a decision as body
+func (w *Webhook) post(ctx context.Context, line string) error {
+	body := []byte(line)
+	request, err := http.NewRequest(http.MethodPost, w.URL, strings.NewReader(line))
+	if err != nil {
+		return err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+	response, err := w.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+	return statusError{
+		status: response.StatusCode,
+		permanent: response.StatusCode >= 400 && response.StatusCode < 500 &&
+			response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests,
+	}
+}
+
+// enqueue appends a decision not delivered to the queue and to the queue file
+func (w *Webhook) enqueue(line string) {
+	w.queue = append(w.queue, line)
+	if w.QueueFileName == "" {
+		return
+	}
+	if w.queueFile == nil {
+		f, err := os.OpenFile(w.QueueFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			w.report(line, err)
+			return
+		}
+		w.queueFile = f
+	}
+	if _, err := w.queueFile.WriteString(line + "\n"); err != nil {
+		w.report(line, err)
+		return
+	}
+	w.queueLines++
+}
+
+// compactQueue removes the delivered decisions from the queue file, the file being removed once the queue is empty
+// and rewritten only when most of its lines were delivered, so that queueing stays linear
+func (w *Webhook) compactQueue() error {
+	if w.QueueFileName == "" || (len(w.queue) > 0 && len(w.queue)*2 > w.queueLines) {
+		return nil
+	}
+	if err := w.closeQueue(); err != nil {
+		return err
+	}
+	w.queueLines = len(w.queue)
+	if len(w.queue) == 0 {
+		if err := os.Remove(w.QueueFileName); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return fileutils.WriteFileAtomic(w.QueueFileName, []byte(strings.Join(w.queue, "\n")+"\n"))
+}
+
+// closeQueue closes the queue file if open
+func (w *Webhook) closeQueue() error {
+	if w.queueFile == nil {
+		return nil
+	}
+	err := w.queueFile.Close()
+	w.queueFile = nil
+	return err
+}
+
+// permanent tells if a delivery failed for a reason retrying cannot fix
+func permanent(err error) bool {
+	statusErr, isStatus := err.(statusError)
+	return isStatus && statusErr.permanent
+}
+
+// readQueue reads the decisions of a queue file, none if it does not exist
+func readQueue(filename string) ([]string, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var queue []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			queue = append(queue, line)
+		}
+	}
+	return queue, scanner.Err()
+}
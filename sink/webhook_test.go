@@ -0,0 +1,200 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver local webhook answering with the given statuses in turn, then 200 unless down, and keeping the delivered
+// bodies
+type receiver struct {
+	mutex     sync.Mutex
+	down      bool
+	statuses  []int
+	delivered []string
+	requests  int
+	signature string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	body, _ := ioutil.ReadAll(req.Body)
+	r.requests++
+	r.signature = req.Header.Get(SignatureHeader)
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	if r.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	r.delivered = append(r.delivered, string(body))
+}
+
+func Test_WebhookSend(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantCloseErr bool
+		wantRefused  int
+		wantRequests int
+		wantPending  int
+	}{
+		{name: "delivered", wantRequests: 1},
+		{name: "retried", statuses: []int{500, 503}, wantRequests: 3},
+		{name: "queuedThenDelivered", statuses: []int{500, 500, 500}, wantRequests: 4},
+		{name: "queued", statuses: []int{500, 500, 500, 500, 500, 500}, wantCloseErr: true, wantRequests: 6, wantPending: 1},
+		{name: "refused", statuses: []int{400}, wantRefused: 1, wantRequests: 1},
+		{name: "tooManyRequests", statuses: []int{429}, wantRequests: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &receiver{statuses: tt.statuses}
+			server := httptest.NewServer(r)
+			defer server.Close()
+			webhook, err := NewWebhook(server.URL, "secret", filepath.Join(t.TempDir(), "queue.txt"))
+			if err != nil {
+				t.Fatalf("NewWebhook error %v", err)
+			}
+			webhook.Attempts = 3
+			webhook.Backoff = time.Millisecond
+			refused := 0
+			webhook.OnError = func(line string, err error) { refused++ }
+			if err := webhook.Send(context.Background(), `{"id":"1"}`); err != nil {
+				t.Fatalf("Send error %v", err)
+			}
+			err = webhook.Close()
+			if (err != nil) != tt.wantCloseErr || refused != tt.wantRefused || r.requests != tt.wantRequests || webhook.Pending() != tt.wantPending {
+				t.Errorf("Close = %v with %d refused, %d requests and %d pending, want error %v, %d refused, %d requests and %d pending",
+					err, refused, r.requests, webhook.Pending(), tt.wantCloseErr, tt.wantRefused, tt.wantRequests, tt.wantPending)
+			}
+			if r.signature != Sign("secret", []byte(`{"id":"1"}`)) {
+				t.Errorf("signature = %s, want %s", r.signature, Sign("secret", []byte(`{"id":"1"}`)))
+			}
+		})
+	}
+}
+
+func Test_WebhookQueue(t *testing.T) {
+	queueFileName := filepath.Join(t.TempDir(), "queue.txt")
+	r := &receiver{down: true}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	webhook, err := NewWebhook(server.URL, "", queueFileName)
+	if err != nil {
+		t.Fatalf("NewWebhook error %v", err)
+	}
+	webhook.Attempts = 2
+	webhook.Backoff = time.Millisecond
+	for _, line := range []string{`{"id":"1"}`, `{"id":"2"}`} {
+		if err := webhook.Send(context.Background(), line); err != nil {
+			t.Fatalf("Send error %v", err)
+		}
+	}
+	if err := webhook.Close(); err == nil || r.signature != "" || webhook.Pending() != 2 || len(r.delivered) != 0 {
+		t.Fatalf("Close = %v with signature %q and %d pending, want an error, no signature and 2 pending", err, r.signature, webhook.Pending())
+	}
+	restarted, err := NewWebhook(server.URL, "", queueFileName)
+	if err != nil || restarted.Pending() != 2 {
+		t.Fatalf("NewWebhook = %d pending and %v, want 2 pending from the queue file", restarted.Pending(), err)
+	}
+	r.mutex.Lock()
+	r.down = false
+	r.mutex.Unlock()
+	restarted.Backoff = time.Millisecond
+	if err := restarted.Send(context.Background(), `{"id":"3"}`); err != nil {
+		t.Fatalf("Send error %v", err)
+	}
+	if err := restarted.Close(); err != nil {
+		t.Fatalf("Close error %v", err)
+	}
+	want := []string{`{"id":"1"}`, `{"id":"2"}`, `{"id":"3"}`}
+	if len(r.delivered) != len(want) || r.delivered[0] != want[0] || r.delivered[1] != want[1] || r.delivered[2] != want[2] {
+		t.Errorf("delivered %v, want %v", r.delivered, want)
+	}
+	if queue, err := readQueue(queueFileName); err != nil || len(queue) != 0 {
+		t.Errorf("queue file holds %v and %v, want it removed", queue, err)
+	}
+}
+
+func Test_WebhookDown(t *testing.T) {
+	queueFileName := filepath.Join(t.TempDir(), "queue.txt")
+	r := &receiver{down: true}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	webhook, err := NewWebhook(server.URL, "", queueFileName)
+	if err != nil {
+		t.Fatalf("NewWebhook error %v", err)
+	}
+	webhook.Attempts = 2
+	webhook.Backoff = time.Millisecond
+	webhook.MaxBackoff = 50 * time.Millisecond
+	webhook.BufferSize = 10
+	for i := 0; i < 200; i++ {
+		if err := webhook.Send(context.Background(), fmt.Sprintf(`{"id":"%d"}`, i)); err != nil {
+			t.Fatalf("Send error %v", err)
+		}
+	}
+	if err := webhook.Close(); err == nil || webhook.Pending() != 200 {
+		t.Fatalf("Close = %v with %d pending, want an error and 200 pending", err, webhook.Pending())
+	}
+	if r.requests >= 100 {
+		t.Errorf("%d requests, want the queued decisions not to be tried each", r.requests)
+	}
+	queue, err := readQueue(queueFileName)
+	if err != nil || len(queue) != 200 || queue[0] != `{"id":"0"}` || queue[199] != `{"id":"199"}` {
+		t.Errorf("queue file holds %d decisions and %v, want the 200 in order", len(queue), err)
+	}
+}
+
+func Test_WebhookSendCancelled(t *testing.T) {
+	r := &receiver{down: true}
+	server := httptest.NewServer(r)
+	defer server.Close()
+	webhook, err := NewWebhook(server.URL, "", "")
+	if err != nil {
+		t.Fatalf("NewWebhook error %v", err)
+	}
+	webhook.Attempts = 1000
+	webhook.Backoff = time.Millisecond
+	webhook.MaxBackoff = time.Millisecond
+	webhook.BufferSize = 1
+	for _, line := range []string{`{"id":"1"}`, `{"id":"2"}`} { // the first one being retried, the second one buffered
+		if err := webhook.Send(context.Background(), line); err != nil {
+			t.Fatalf("Send error %v", err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sent := make(chan error, 1)
+	go func() {
+		sent <- webhook.Send(ctx, `{"id":"3"}`)
+	}()
+	select {
+	case err := <-sent:
+		if err != context.Canceled || webhook.Pending() != 2 {
+			t.Errorf("Send = %v with %d pending, want %v and 2 pending", err, webhook.Pending(), context.Canceled)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Send with a full buffer still blocks once cancelled")
+	}
+	r.mutex.Lock()
+	r.down = false
+	r.mutex.Unlock()
+	if err := webhook.Close(); err != nil || len(r.delivered) != 2 {
+		t.Errorf("Close = %v with %v delivered, want the 2 sent decisions", err, r.delivered)
+	}
+}
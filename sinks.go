@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"github.com/vincentcreusot/finance-limits/sink"
+	"log"
+	"os"
+)
+
+// webhookSecretVariable environment variable holding the webhook secret when not given as flag
+const webhookSecretVariable = "FINANCE_LIMITS_WEBHOOK_SECRET"
+
+// openSinks gives the sinks receiving the decisions in addition to the output file
+func openSinks(options runOptions) sink.Multi {
+	var sinks sink.Multi
+	if options.stdout {
+		sinks = append(sinks, sink.NewStdout())
+	}
+	if options.webhookURL != "" {
+		secret := options.webhookSecret
+		if secret == "" {
+			secret = os.Getenv(webhookSecretVariable)
+		}
+		webhook, err := sink.NewWebhook(options.webhookURL, secret, options.webhookQueueFileName)
+		if err != nil {
+			log.Fatalln("Error reading webhook queue:", err)
+		}
+		if options.webhookAttempts > 0 {
+			webhook.Attempts = options.webhookAttempts
+		}
+		webhook.OnError = func(line string, err error) {
+			log.Println("Error delivering decision to webhook:", err, line)
+		}
+		sinks = append(sinks, webhook)
+	}
+	return sinks
+}
+
+// sendDecision sends a decision to the sinks, a failure being logged without stopping the run
+func sendDecision(ctx context.Context, sinks sink.Sink, line string) {
+	if err := sinks.Send(ctx, line); err != nil {
+		log.Println("Error sending decision:", err)
+	}
+}
+
+// closeSinks closes the sinks, delivering what is still queued
+func closeSinks(sinks sink.Sink) {
+	if err := sinks.Close(); err != nil {
+		log.Println("Error closing sinks:", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+// sortCommand sorts load files by time into one output file
+func sortCommand(args []string) {
+	inputFileName := ""
+	outputFileName := ""
+	chunkLines := 0
+	tempDir := ""
+	flags := flag.NewFlagSet("sort", flag.ExitOnError)
+	flags.StringVar(&inputFileName, "inputFile", "", "File to sort, or comma separated files and glob patterns")
+	flags.StringVar(&inputFileName, "i", "", "File to sort, or comma separated files and glob patterns")
+	flags.StringVar(&outputFileName, "outputFile", "", "File to write the sorted loads to")
+	flags.StringVar(&outputFileName, "o", "", "File to write the sorted loads to")
+	flags.IntVar(&chunkLines, "chunkLines", fileutils.DefaultChunkLines, "Number of lines sorted in memory at once")
+	flags.StringVar(&tempDir, "tempDir", "", "Directory of the temporary files, the system one by default")
+	_ = flags.Parse(args)
+	if inputFileName == "" || outputFileName == "" {
+		fmt.Println("flags -inputFile and -outputFile are needed")
+		flags.Usage()
+		os.Exit(1)
+	}
+	inputFileNames, err := fileutils.InputFiles(inputFileName)
+	if err != nil {
+		log.Fatalln("Error in input files:", err)
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+	if err := fileutils.SortLines(ctx, inputFileNames, outputFileName, chunkLines, tempDir); err != nil {
+		log.Fatalln("Error sorting loads:", err)
+	}
+}
+
+// sortInput sorts the input files of a run into a temporary file which becomes its input, removed by the returned
+// function
+func sortInput(ctx context.Context, options *runOptions) func() {
+	sortedFile, err := ioutil.TempFile(options.sortTempDir, "finance-limits-sorted")
+	if err != nil {
+		log.Fatalln("Error creating sorted input:", err)
+	}
+	sortedFile.Close()
+	remove := func() {
+		os.Remove(sortedFile.Name())
+	}
+	if err := fileutils.SortLines(ctx, options.inputFileNames, sortedFile.Name(), options.sortChunkLines, options.sortTempDir); err != nil {
+		remove()
+		log.Fatalln("Error sorting input:", err)
+	}
+	options.inputFileName = sortedFile.Name()
+	options.inputFileNames = []string{sortedFile.Name()}
+	return remove
+}
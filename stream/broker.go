@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// Broker in-process stand-in of a message broker holding topics of one partition in memory
+type Broker struct {
+	mutex   sync.Mutex
+	topics  map[string][]Message
+	changed chan struct{}
+}
+
+// brokerTopic consumer and producer of a topic of a Broker
+type brokerTopic struct {
+	broker *Broker
+	topic  string
+}
+
+// NewBroker creates a broker without any message
+func NewBroker() *Broker {
+	return &Broker{
+		topics:  make(map[string][]Message),
+		changed: make(chan struct{}),
+	}
+}
+
+// Consumer gives a consumer of a topic
+func (b *Broker) Consumer(topic string) Consumer {
+	return brokerTopic{broker: b, topic: topic}
+}
+
+// Producer gives a producer to a topic
+func (b *Broker) Producer(topic string) Producer {
+	return brokerTopic{broker: b, topic: topic}
+}
+
+// Messages gives the messages of a topic
+func (b *Broker) Messages(topic string) []Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]Message(nil), b.topics[topic]...)
+}
+
+// Consume sends the messages of the topic from an offset, waiting for new ones until the context is done
+func (t brokerTopic) Consume(ctx context.Context, offset int64, messages chan<- Message) error {
+	defer close(messages)
+	for {
+		t.broker.mutex.Lock()
+		topic := t.broker.topics[t.topic]
+		changed := t.broker.changed
+		t.broker.mutex.Unlock()
+		if offset < int64(len(topic)) {
+			select {
+			case messages <- topic[offset]:
+				offset++
+			case <-ctx.Done():
+				return nil
+			}
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Produce appends messages to the topic, their offsets being given by the broker
+func (t brokerTopic) Produce(ctx context.Context, messages ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.broker.mutex.Lock()
+	defer t.broker.mutex.Unlock()
+	for _, message := range messages {
+		message.Offset = int64(len(t.broker.topics[t.topic]))
+		t.broker.topics[t.topic] = append(t.broker.topics[t.topic], message)
+	}
+	close(t.broker.changed)
+	t.broker.changed = make(chan struct{})
+	return nil
+}
+
+// EndOffset gives the number of messages of the topic
+func (t brokerTopic) EndOffset(ctx context.Context) (int64, error) {
+	t.broker.mutex.Lock()
+	defer t.broker.mutex.Unlock()
+	return int64(len(t.broker.topics[t.topic])), nil
+}
@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"time"
+)
+
+const kafkaTimeout = 10 * time.Second
+
+// KafkaConsumer consumer of one partition of a Kafka topic
+type KafkaConsumer struct {
+	Brokers   []string
+	Topic     string
+	Partition int
+}
+
+// KafkaProducer producer to one partition of a Kafka topic, through a connection to its leader
+type KafkaProducer struct {
+	conn *kafka.Conn
+}
+
+// Consume sends the messages of the partition from an offset, waiting for new ones until the context is done
+func (c KafkaConsumer) Consume(ctx context.Context, offset int64, messages chan<- Message) error {
+	defer close(messages)
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   c.Brokers,
+		Topic:     c.Topic,
+		Partition: c.Partition,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(offset); err != nil {
+		return err
+	}
+	for {
+		message, err := reader.ReadMessage(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case messages <- Message{Offset: message.Offset, Key: string(message.Key), Value: string(message.Value)}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// DialKafkaProducer connects to the leader of a partition of a topic through the first broker answering
+func DialKafkaProducer(ctx context.Context, brokers []string, topic string, partition int) (*KafkaProducer, error) {
+	err := errors.New("no broker given")
+	for _, broker := range brokers {
+		var conn *kafka.Conn
+		if conn, err = kafka.DialLeader(ctx, "tcp", broker, topic, partition); err == nil {
+			return &KafkaProducer{conn: conn}, nil
+		}
+	}
+	return nil, err
+}
+
+// Produce writes messages to the partition
+func (p *KafkaProducer) Produce(ctx context.Context, messages ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	kafkaMessages := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		kafkaMessages[i] = kafka.Message{Key: []byte(message.Key), Value: []byte(message.Value)}
+	}
+	if err := p.conn.SetWriteDeadline(time.Now().Add(kafkaTimeout)); err != nil {
+		return err
+	}
+	_, err := p.conn.WriteMessages(kafkaMessages...)
+	return err
+}
+
+// EndOffset gives the offset of the next message of the partition
+func (p *KafkaProducer) EndOffset(ctx context.Context) (int64, error) {
+	return p.conn.ReadLastOffset()
+}
+
+// Close closes the connection to the leader
+func (p *KafkaProducer) Close() error {
+	return p.conn.Close()
+}
@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/checkpoint"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"log"
+)
+
+// Processor validates the loads of the input topic and produces their responses to the output topic
+// the consumed offset and the offset of the next response are saved in a checkpoint with the state of the engine, so
+// after a restart the loads consumed since the checkpoint are validated again and only the responses not produced
+// yet are produced, each response being produced exactly once
+// the audit log, if given, is brought back to the checkpoint so the loads validated again are not audited twice
+type Processor struct {
+	Engine             *logic.FinanceLogic
+	Consumer           Consumer
+	Producer           Producer
+	CheckpointFileName string
+	CheckpointInterval int
+	AuditLog           *audit.Log
+	// Restored is called once the engine is restored from the checkpoint
+	Restored func()
+	// Produced is called with each response produced
+	Produced func(line string)
+	// Checkpointing is called before each checkpoint is saved
+	Checkpointing func()
+}
+
+// Run consumes the input topic until the context is done, saving a checkpoint every interval of messages and when
+// stopping, the response of a load being validated when the context is done still being produced
+// a first checkpoint records where the output topic ended, and no checkpoint is saved when producing fails, the
+// responses after the last checkpoint being checked again on restart
+func (p *Processor) Run(ctx context.Context) error {
+	current, exist, err := checkpoint.Load(p.CheckpointFileName)
+	if err != nil {
+		return err
+	}
+	if exist {
+		if p.AuditLog != nil && current.Audit != nil {
+			if err := p.AuditLog.Truncate(*current.Audit); err != nil {
+				return err
+			}
+		}
+		p.Engine.Restore(current.State)
+		log.Printf("Resuming after %d messages at offset %d\n", current.ParsedLines, current.Input.Offset)
+	}
+	if p.Restored != nil {
+		p.Restored()
+	}
+	produced, err := p.Producer.EndOffset(ctx)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		current.OutputOffset = produced
+		if err := p.save(current); err != nil {
+			return err
+		}
+	}
+	if produced < current.OutputOffset {
+		return fmt.Errorf("output topic ends at offset %d, before the checkpoint offset %d", produced, current.OutputOffset)
+	}
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lineChannel := make(chan fileutils.Line)
+	readErrors := make(chan error, 1)
+	go func() {
+		readErrors <- ReadLines(readCtx, p.Consumer, current.Input.Offset, lineChannel)
+	}()
+	errCount := 0
+	for line := range lineChannel {
+		if ctx.Err() != nil { // the line is consumed again after the restart
+			cancel()
+			for range lineChannel {
+			}
+			break
+		}
+		response, err := p.Engine.ParseLoad(line.Text)
+		if err != nil {
+			log.Printf("Error #%d in load: %v\n", errCount, err)
+			errCount++
+		}
+		if response != "" {
+			if current.OutputOffset == produced {
+				// a decided response is produced even if the run is stopping, to be saved in the last checkpoint
+				if err := p.Producer.Produce(context.Background(), Message{Value: response}); err != nil {
+					cancel()
+					for range lineChannel {
+					}
+					return err
+				}
+				produced++
+				if p.Produced != nil {
+					p.Produced(response)
+				}
+			}
+			current.OutputOffset++
+		}
+		current.Input = line.Position
+		current.ParsedLines++
+		if p.CheckpointInterval > 0 && current.ParsedLines%p.CheckpointInterval == 0 {
+			if err := p.save(current); err != nil {
+				cancel()
+				for range lineChannel {
+				}
+				return err
+			}
+		}
+	}
+	if err := <-readErrors; err != nil {
+		return err
+	}
+	return p.save(current)
+}
+
+// save saves a checkpoint with the state of the engine and the position of the audit log
+func (p *Processor) save(current checkpoint.Checkpoint) error {
+	if p.Checkpointing != nil {
+		p.Checkpointing()
+	}
+	current.State = p.Engine.Snapshot()
+	if p.AuditLog != nil {
+		position, err := p.AuditLog.Position()
+		if err != nil {
+			return err
+		}
+		current.Audit = &position
+	}
+	return checkpoint.Save(p.CheckpointFileName, current)
+}
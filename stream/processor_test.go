@@ -0,0 +1,215 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+	"github.com/vincentcreusot/finance-limits/logic"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var loads = []string{
+	`{"id":"1","customer_id":"1","load_amount":"$3000.00","time":"2020-01-06T10:00:00Z"}`,
+	`{"id":"2","customer_id":"1","load_amount":"$3000.00","time":"2020-01-06T11:00:00Z"}`,
+	`{"id":"3","customer_id":"2","load_amount":"$100.00","time":"2020-01-06T12:00:00Z"}`,
+	`{"id":"3","customer_id":"2","load_amount":"$100.00","time":"2020-01-06T12:00:00Z"}`,
+	`{"id":"4","customer_id":"1","load_amount":"$1000.00","time":"2020-01-06T13:00:00Z"}`,
+	`{"id":"5","customer_id":"2","load_amount":"$5000.00","time":"2020-01-06T14:00:00Z"}`,
+}
+
+var responses = []string{
+	`{"id":"1","customer_id":"1","accepted":true}`,
+	`{"id":"2","customer_id":"1","accepted":false}`,
+	`{"id":"3","customer_id":"2","accepted":true}`,
+	`{"id":"4","customer_id":"1","accepted":true}`,
+	`{"id":"5","customer_id":"2","accepted":false}`,
+}
+
+// failingProducer producer failing after a number of messages, as a crash would
+type failingProducer struct {
+	Producer
+	remaining int
+}
+
+func (p *failingProducer) Produce(ctx context.Context, messages ...Message) error {
+	if p.remaining == 0 {
+		return errors.New("producer crashed")
+	}
+	p.remaining--
+	return p.Producer.Produce(ctx, messages...)
+}
+
+// stoppingProducer producer stopping the run when producing a number of messages, as a signal received while a load
+// is validated would
+type stoppingProducer struct {
+	Producer
+	remaining int
+	stop      func()
+}
+
+func (p *stoppingProducer) Produce(ctx context.Context, messages ...Message) error {
+	if p.remaining--; p.remaining == 0 {
+		p.stop()
+	}
+	return p.Producer.Produce(ctx, messages...)
+}
+
+// runUntil runs a processor until its output topic holds a number of messages
+func runUntil(t *testing.T, broker *Broker, processor *Processor, outputs int) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErrors := make(chan error, 1)
+	go func() {
+		runErrors <- processor.Run(ctx)
+	}()
+	deadline := time.After(5 * time.Second)
+	for len(broker.Messages("decisions")) < outputs {
+		select {
+		case err := <-runErrors:
+			return err
+		case <-deadline:
+			t.Fatalf("output topic holds %d messages, want %d", len(broker.Messages("decisions")), outputs)
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	return <-runErrors
+}
+
+func Test_ProcessorRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval int
+		crashes  []int
+	}{
+		{name: "uninterrupted", interval: 2},
+		{name: "crashBeforeCheckpoint", interval: 4, crashes: []int{3}},
+		{name: "crashAfterCheckpoint", interval: 2, crashes: []int{2, 3}},
+		{name: "crashWithoutCheckpoint", interval: 100, crashes: []int{1, 2, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewBroker()
+			broker.Producer("decisions").Produce(context.Background(), Message{Value: "previous run"})
+			for _, load := range loads {
+				broker.Producer("loads").Produce(context.Background(), Message{Value: load})
+			}
+			checkpointFileName := filepath.Join(t.TempDir(), "checkpoint.json")
+			auditFileName := filepath.Join(t.TempDir(), "audit.log")
+			newProcessor := func(producer Producer) *Processor {
+				auditLog, err := audit.Open(auditFileName)
+				if err != nil {
+					t.Fatalf("Open error %v", err)
+				}
+				t.Cleanup(func() { auditLog.Close() })
+				engine := logic.NewFinanceLogic()
+				engine.Auditor = auditLog
+				return &Processor{
+					Engine:             engine,
+					Consumer:           broker.Consumer("loads"),
+					Producer:           producer,
+					CheckpointFileName: checkpointFileName,
+					CheckpointInterval: tt.interval,
+					AuditLog:           auditLog,
+				}
+			}
+			produced := 0
+			for _, crash := range tt.crashes {
+				processor := newProcessor(&failingProducer{Producer: broker.Producer("decisions"), remaining: crash - produced})
+				if err := runUntil(t, broker, processor, len(responses)+1); err == nil {
+					t.Fatalf("Run gives no error after crashing at %d", crash)
+				}
+				produced = len(broker.Messages("decisions")) - 1
+			}
+			if err := runUntil(t, broker, newProcessor(broker.Producer("decisions")), len(responses)+1); err != nil {
+				t.Fatalf("Run error %v", err)
+			}
+			messages := broker.Messages("decisions")[1:]
+			if len(messages) != len(responses) {
+				t.Fatalf("output topic holds %d responses, want %d", len(messages), len(responses))
+			}
+			for i, message := range messages {
+				if message.Value != responses[i] || message.Offset != int64(i+1) {
+					t.Errorf("response %d = %s at %d, want %s", i, message.Value, message.Offset, responses[i])
+				}
+			}
+			if audited, err := audit.Verify(auditFileName); err != nil || audited != uint64(len(responses)) {
+				t.Errorf("audit log holds %d decisions and %v, want %d", audited, err, len(responses))
+			}
+		})
+	}
+}
+
+func Test_ReadLines(t *testing.T) {
+	broker := NewBroker()
+	for _, load := range loads[:3] {
+		broker.Producer("loads").Produce(context.Background(), Message{Value: load})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lineChannel := make(chan fileutils.Line)
+	readErrors := make(chan error, 1)
+	go func() {
+		readErrors <- ReadLines(ctx, broker.Consumer("loads"), 1, lineChannel)
+	}()
+	for i, load := range loads[1:4] {
+		if i == 2 {
+			broker.Producer("loads").Produce(context.Background(), Message{Value: load})
+		}
+		line := <-lineChannel
+		if line.Text != load || line.Position.Offset != int64(i+2) {
+			t.Errorf("line %d = %s at %d, want %s at %d", i, line.Text, line.Position.Offset, load, i+2)
+		}
+	}
+	cancel()
+	for range lineChannel {
+	}
+	if err := <-readErrors; err != nil {
+		t.Errorf("ReadLines error %v", err)
+	}
+}
+
+func Test_ProcessorRunStopped(t *testing.T) {
+	broker := NewBroker()
+	for _, load := range loads {
+		broker.Producer("loads").Produce(context.Background(), Message{Value: load})
+	}
+	checkpointFileName := filepath.Join(t.TempDir(), "checkpoint.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := &Processor{
+		Engine:             logic.NewFinanceLogic(),
+		Consumer:           broker.Consumer("loads"),
+		Producer:           &stoppingProducer{Producer: broker.Producer("decisions"), remaining: 2, stop: cancel},
+		CheckpointFileName: checkpointFileName,
+		CheckpointInterval: 100,
+	}
+	if err := stopped.Run(ctx); err != nil {
+		t.Fatalf("Run stopped while producing gives error %v", err)
+	}
+	if produced := len(broker.Messages("decisions")); produced != 2 {
+		t.Fatalf("output topic holds %d responses when stopped, want 2", produced)
+	}
+	restarted := &Processor{
+		Engine:             logic.NewFinanceLogic(),
+		Consumer:           broker.Consumer("loads"),
+		Producer:           broker.Producer("decisions"),
+		CheckpointFileName: checkpointFileName,
+		CheckpointInterval: 100,
+	}
+	if err := runUntil(t, broker, restarted, len(responses)); err != nil {
+		t.Fatalf("Run error %v", err)
+	}
+	messages := broker.Messages("decisions")
+	if len(messages) != len(responses) {
+		t.Fatalf("output topic holds %d responses, want %d", len(messages), len(responses))
+	}
+	for i, message := range messages {
+		if message.Value != responses[i] {
+			t.Errorf("response %d = %s, want %s", i, message.Value, responses[i])
+		}
+	}
+}
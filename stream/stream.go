@@ -0,0 +1,47 @@
+package stream
+
+import (
+	"context"
+	"github.com/vincentcreusot/finance-limits/fileutils"
+)
+
+// Message one record of a topic partition
+type Message struct {
+	Offset int64
+	Key    string
+	Value  string
+}
+
+// Consumer reads the messages of one partition of the input topic
+type Consumer interface {
+	// Consume sends the messages from an offset until the context is done, closing the channel when returning
+	Consume(ctx context.Context, offset int64, messages chan<- Message) error
+}
+
+// Producer appends messages to one partition of the output topic
+type Producer interface {
+	Produce(ctx context.Context, messages ...Message) error
+	// EndOffset gives the offset the next produced message will get
+	EndOffset(ctx context.Context) (int64, error)
+}
+
+// ReadLines sends the value of each message from an offset with the offset after it, like fileutils.ReadLinesFrom
+// for a file, until the context is done
+func ReadLines(ctx context.Context, consumer Consumer, offset int64, lineChannel chan fileutils.Line) error {
+	defer close(lineChannel)
+	messages := make(chan Message)
+	consumeErrors := make(chan error, 1)
+	go func() {
+		consumeErrors <- consumer.Consume(ctx, offset, messages)
+	}()
+	for message := range messages {
+		select {
+		case lineChannel <- fileutils.Line{Text: message.Value, Position: fileutils.Position{Offset: message.Offset + 1}}:
+		case <-ctx.Done():
+			for range messages {
+			}
+			return <-consumeErrors
+		}
+	}
+	return <-consumeErrors
+}
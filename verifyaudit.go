@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/vincentcreusot/finance-limits/audit"
+	"os"
+)
+
+// verifyAuditCommand checks the hash chain of an audit log
+func verifyAuditCommand(args []string) {
+	auditFileName := ""
+	flags := flag.NewFlagSet("verify-audit", flag.ExitOnError)
+	flags.StringVar(&auditFileName, "auditFile", "", "Audit log to verify")
+	flags.StringVar(&auditFileName, "a", "", "Audit log to verify")
+	_ = flags.Parse(args)
+	if auditFileName == "" {
+		fmt.Println("flag -auditFile is needed")
+		flags.Usage()
+		os.Exit(1)
+	}
+	records, err := audit.Verify(auditFileName)
+	if err != nil {
+		fmt.Printf("Audit log invalid after %d valid records: %v\n", records, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Audit log valid with %d records\n", records)
+}